@@ -0,0 +1,323 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// SymbolTag is an extra annotation of a symbol that tweaks its
+// rendering.
+type SymbolTag int
+
+const STDeprecated SymbolTag = 1
+
+type CallHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+}
+
+// CallHierarchyItem is a function-like symbol (such as a function,
+// method or constructor) in a call hierarchy.
+type CallHierarchyItem struct {
+	Name   string      `json:"name"`
+	Kind   SymbolKind  `json:"kind"`
+	Tags   []SymbolTag `json:"tags,omitempty"`
+	Detail string      `json:"detail,omitempty"`
+	URI    DocumentURI `json:"uri"`
+
+	// Range is the range of the whole symbol, including its body and
+	// comments.
+	Range Range `json:"range"`
+
+	// SelectionRange is the range of the name of the symbol, within
+	// Range.
+	SelectionRange Range `json:"selectionRange"`
+
+	// Data is preserved between a textDocument/prepareCallHierarchy
+	// request and the callHierarchy/incomingCalls and
+	// callHierarchy/outgoingCalls requests.
+	Data interface{} `json:"data,omitempty"`
+}
+
+type CallHierarchyIncomingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+type CallHierarchyIncomingCall struct {
+	// From is the item that makes the call.
+	From CallHierarchyItem `json:"from"`
+
+	// FromRanges are the ranges of the calls, within From.
+	FromRanges []Range `json:"fromRanges"`
+}
+
+type CallHierarchyOutgoingCallsParams struct {
+	Item CallHierarchyItem `json:"item"`
+}
+
+type CallHierarchyOutgoingCall struct {
+	// To is the item that is called.
+	To CallHierarchyItem `json:"to"`
+
+	// FromRanges are the ranges of the calls, within the item of the
+	// request (not within To).
+	FromRanges []Range `json:"fromRanges"`
+}
+
+// CallHierarchyDirection is the direction in which WalkCallHierarchy
+// follows calls.
+type CallHierarchyDirection int
+
+const (
+	// CHDIncoming follows the calls to an item, with
+	// callHierarchy/incomingCalls requests.
+	CHDIncoming CallHierarchyDirection = iota
+
+	// CHDOutgoing follows the calls made by an item, with
+	// callHierarchy/outgoingCalls requests.
+	CHDOutgoing
+)
+
+const (
+	// DefaultMaxCallGraphDepth is the depth walked by
+	// WalkCallHierarchy when the MaxDepth field of CallGraphOptions is
+	// zero.
+	DefaultMaxCallGraphDepth = 5
+
+	// DefaultCallGraphConcurrency is the number of concurrent requests
+	// made by WalkCallHierarchy when the Concurrency field of
+	// CallGraphOptions is zero.
+	DefaultCallGraphConcurrency = 4
+)
+
+// CallGraphOptions controls WalkCallHierarchy.
+type CallGraphOptions struct {
+	// Direction is the direction in which calls are followed.
+	Direction CallHierarchyDirection
+
+	// MaxDepth is the number of calls followed from the roots. If
+	// zero, DefaultMaxCallGraphDepth is used; if negative, the whole
+	// graph is walked.
+	MaxDepth int
+
+	// Concurrency is the maximum number of requests in flight. If
+	// zero, DefaultCallGraphConcurrency is used.
+	Concurrency int
+}
+
+// CallGraph is a graph of calls between call hierarchy items, as
+// returned by WalkCallHierarchy. It can be encoded as JSON, or in the
+// DOT language with WriteDOT.
+type CallGraph struct {
+	Nodes []CallGraphNode `json:"nodes"`
+	Edges []CallGraphEdge `json:"edges"`
+}
+
+type CallGraphNode struct {
+	Item CallHierarchyItem `json:"item"`
+
+	// Depth is the number of calls between a root and the item.
+	Depth int `json:"depth"`
+
+	// Truncated reports whether the calls of the item were not
+	// requested, because the maximum depth was reached.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// CallGraphEdge is a call from one node of a CallGraph to another (or
+// to itself), identified by their indexes in Nodes.
+type CallGraphEdge struct {
+	Caller int `json:"caller"`
+	Callee int `json:"callee"`
+
+	// Ranges are the ranges of the calls, within the caller.
+	Ranges []Range `json:"ranges"`
+}
+
+// callGraphKey identifies a call hierarchy item, to detect cycles.
+type callGraphKey struct {
+	uri            DocumentURI
+	name           string
+	selectionRange Range
+}
+
+func callGraphKeyOf(item *CallHierarchyItem) callGraphKey {
+	return callGraphKey{uri: item.URI, name: item.Name, selectionRange: item.SelectionRange}
+}
+
+// WalkCallHierarchy returns the graph of the calls to or from (as
+// chosen by opts.Direction) the roots, which are typically the result
+// of a textDocument/prepareCallHierarchy request. If opts is nil, the
+// defaults described in CallGraphOptions are used.
+//
+// The graph is walked breadth-first, level by level, requesting the
+// calls of the items of a level concurrently. Items are identified by
+// their URI, name and selection range: an item reached more than once
+// (for instance, through recursion) is a single node whose calls are
+// requested once. The order of the nodes and edges does not depend on
+// the order in which responses arrive. The walk stops at the first
+// error, which is returned.
+func WalkCallHierarchy(ctx context.Context, c *Client, roots []CallHierarchyItem, opts *CallGraphOptions) (*CallGraph, error) {
+	if opts == nil {
+		opts = &CallGraphOptions{}
+	}
+	maxDepth := opts.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxCallGraphDepth
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCallGraphConcurrency
+	}
+
+	g := &CallGraph{Nodes: []CallGraphNode{}, Edges: []CallGraphEdge{}}
+	nodes := map[callGraphKey]int{}
+	edges := map[[2]int]int{}
+	addNode := func(item CallHierarchyItem, depth int) (int, bool) {
+		key := callGraphKeyOf(&item)
+		if i, ok := nodes[key]; ok {
+			return i, false
+		}
+		nodes[key] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, CallGraphNode{Item: item, Depth: depth})
+		return len(g.Nodes) - 1, true
+	}
+	addEdge := func(caller, callee int, ranges []Range) {
+		if i, ok := edges[[2]int{caller, callee}]; ok {
+			g.Edges[i].Ranges = append(g.Edges[i].Ranges, ranges...)
+			return
+		}
+		edges[[2]int{caller, callee}] = len(g.Edges)
+		g.Edges = append(g.Edges, CallGraphEdge{Caller: caller, Callee: callee, Ranges: append([]Range{}, ranges...)})
+	}
+
+	var level []int
+	for _, item := range roots {
+		if i, ok := addNode(item, 0); ok {
+			level = append(level, i)
+		}
+	}
+
+	for depth := 0; len(level) > 0; depth++ {
+		if depth == maxDepth {
+			for _, i := range level {
+				g.Nodes[i].Truncated = true
+			}
+			break
+		}
+
+		calls, err := callGraphLevel(ctx, c, g, level, opts.Direction, concurrency)
+		if err != nil {
+			return nil, err
+		}
+		var next []int
+		for j, i := range level {
+			for _, call := range calls[j] {
+				other, ok := addNode(call.item, depth+1)
+				if ok {
+					next = append(next, other)
+				}
+				if opts.Direction == CHDIncoming {
+					addEdge(other, i, call.ranges)
+				} else {
+					addEdge(i, other, call.ranges)
+				}
+			}
+		}
+		level = next
+	}
+	return g, nil
+}
+
+// callGraphCall is an incoming or outgoing call of a call hierarchy
+// item.
+type callGraphCall struct {
+	item   CallHierarchyItem
+	ranges []Range
+}
+
+// callGraphLevel requests the calls of the nodes of g with the indexes
+// in level, with at most concurrency requests in flight. The calls of
+// level[j] are in the j-th element of the result.
+func callGraphLevel(ctx context.Context, c *Client, g *CallGraph, level []int, dir CallHierarchyDirection, concurrency int) ([][]callGraphCall, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	calls := make([][]callGraphCall, len(level))
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for j, i := range level {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(j int, item CallHierarchyItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var err error
+			if dir == CHDIncoming {
+				var result []CallHierarchyIncomingCall
+				result, err = c.IncomingCalls(ctx, CallHierarchyIncomingCallsParams{Item: item})
+				for _, call := range result {
+					calls[j] = append(calls[j], callGraphCall{item: call.From, ranges: call.FromRanges})
+				}
+			} else {
+				var result []CallHierarchyOutgoingCall
+				result, err = c.OutgoingCalls(ctx, CallHierarchyOutgoingCallsParams{Item: item})
+				for _, call := range result {
+					calls[j] = append(calls[j], callGraphCall{item: call.To, ranges: call.FromRanges})
+				}
+			}
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("lsp: calls of %s (%s): %w", item.Name, item.URI, err)
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(j, g.Nodes[i].Item)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return calls, ctx.Err()
+}
+
+// WriteDOT writes g to w as a directed graph in the DOT language of
+// Graphviz, with edges from callers to callees.
+func (g *CallGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph calls {\n")
+	for i, n := range g.Nodes {
+		fmt.Fprintf(&b, "\tn%d [label=%s, tooltip=%s];\n", i, dotQuote(n.Item.Name), dotQuote(fmt.Sprintf("%s:%d", n.Item.URI, n.Item.SelectionRange.Start.Line+1)))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&b, "\tn%d -> n%d;\n", e.Caller, e.Callee)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotEscaper escapes the characters that cannot appear as is in a
+// quoted DOT string. Unlike in Go, other characters need no escaping.
+var dotEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, "\n", `\n`)
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
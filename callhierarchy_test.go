@@ -0,0 +1,186 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// callServer serves the call hierarchy of a static call graph.
+type callServer struct {
+	UnimplementedLanguageServer
+	calls map[string][]string // caller -> callees
+
+	mu             sync.Mutex
+	inFlight, peak int
+	requested      []string
+}
+
+func callItem(name string) CallHierarchyItem {
+	line := int(name[0] - 'a')
+	return CallHierarchyItem{
+		Name:           name,
+		Kind:           SKFunction,
+		URI:            "file:///a.go",
+		Range:          Range{Start: Position{Line: line}, End: Position{Line: line, Character: 10}},
+		SelectionRange: Range{Start: Position{Line: line, Character: 5}, End: Position{Line: line, Character: 5 + len(name)}},
+	}
+}
+
+func callRange(caller, callee string) Range {
+	line := int(caller[0] - 'a')
+	char := int(callee[0] - 'a')
+	return Range{Start: Position{Line: line, Character: char}, End: Position{Line: line, Character: char + 1}}
+}
+
+func (s *callServer) enter(name string) error {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.requested = append(s.requested, name)
+	s.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+	if name == "error" {
+		return errors.New("no calls")
+	}
+	return nil
+}
+
+func (s *callServer) IncomingCalls(ctx context.Context, params *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, error) {
+	if err := s.enter(params.Item.Name); err != nil {
+		return nil, err
+	}
+	var calls []CallHierarchyIncomingCall
+	for _, caller := range []string{"bar", "baz", "error", "foo", "main", "qux"} {
+		for _, callee := range s.calls[caller] {
+			if callee == params.Item.Name {
+				calls = append(calls, CallHierarchyIncomingCall{From: callItem(caller), FromRanges: []Range{callRange(caller, callee)}})
+			}
+		}
+	}
+	return calls, nil
+}
+
+func (s *callServer) OutgoingCalls(ctx context.Context, params *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, error) {
+	if err := s.enter(params.Item.Name); err != nil {
+		return nil, err
+	}
+	var calls []CallHierarchyOutgoingCall
+	for _, callee := range s.calls[params.Item.Name] {
+		calls = append(calls, CallHierarchyOutgoingCall{To: callItem(callee), FromRanges: []Range{callRange(params.Item.Name, callee)}})
+	}
+	return calls, nil
+}
+
+func TestWalkCallHierarchy(t *testing.T) {
+	s := &callServer{calls: map[string][]string{
+		"main": {"foo", "bar"},
+		"foo":  {"bar"},
+		"bar":  {"foo", "bar", "baz"},
+		"baz":  {"qux"},
+	}}
+	client, _, _ := newTestConns(t, s)
+	ctx := context.Background()
+
+	g, err := WalkCallHierarchy(ctx, client, []CallHierarchyItem{callItem("main")}, &CallGraphOptions{Direction: CHDOutgoing, MaxDepth: 2, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &CallGraph{
+		Nodes: []CallGraphNode{
+			{Item: callItem("main"), Depth: 0},
+			{Item: callItem("foo"), Depth: 1},
+			{Item: callItem("bar"), Depth: 1},
+			{Item: callItem("baz"), Depth: 2, Truncated: true},
+		},
+		Edges: []CallGraphEdge{
+			{Caller: 0, Callee: 1, Ranges: []Range{callRange("main", "foo")}},
+			{Caller: 0, Callee: 2, Ranges: []Range{callRange("main", "bar")}},
+			{Caller: 1, Callee: 2, Ranges: []Range{callRange("foo", "bar")}},
+			{Caller: 2, Callee: 1, Ranges: []Range{callRange("bar", "foo")}},
+			{Caller: 2, Callee: 2, Ranges: []Range{callRange("bar", "bar")}},
+			{Caller: 2, Callee: 3, Ranges: []Range{callRange("bar", "baz")}},
+		},
+	}
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("got %+v, want %+v", g, want)
+	}
+	if s.peak > 2 {
+		t.Errorf("got %d concurrent requests, want at most 2", s.peak)
+	}
+
+	// Each item's calls are requested once, despite the cycles.
+	s.requested = nil
+	g, err = WalkCallHierarchy(ctx, client, []CallHierarchyItem{callItem("bar")}, &CallGraphOptions{Direction: CHDIncoming, MaxDepth: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, n := range g.Nodes {
+		names = append(names, n.Item.Name)
+	}
+	if want := []string{"bar", "foo", "main"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got nodes %v, want %v", names, want)
+	}
+	if len(s.requested) != 3 {
+		t.Errorf("got requests for %v, want one per node", s.requested)
+	}
+	wantEdges := []CallGraphEdge{
+		{Caller: 0, Callee: 0, Ranges: []Range{callRange("bar", "bar")}},
+		{Caller: 1, Callee: 0, Ranges: []Range{callRange("foo", "bar")}},
+		{Caller: 2, Callee: 0, Ranges: []Range{callRange("main", "bar")}},
+		{Caller: 0, Callee: 1, Ranges: []Range{callRange("bar", "foo")}},
+		{Caller: 2, Callee: 1, Ranges: []Range{callRange("main", "foo")}},
+	}
+	if !reflect.DeepEqual(g.Edges, wantEdges) {
+		t.Errorf("got edges %+v, want %+v", g.Edges, wantEdges)
+	}
+
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	wantDOT := `digraph calls {
+	n0 [label="bar", tooltip="file:///a.go:2"];
+	n1 [label="foo", tooltip="file:///a.go:6"];
+	n2 [label="main", tooltip="file:///a.go:13"];
+	n0 -> n0;
+	n1 -> n0;
+	n2 -> n0;
+	n0 -> n1;
+	n2 -> n1;
+}
+`
+	if b.String() != wantDOT {
+		t.Errorf("got DOT:\n%s\nwant:\n%s", b.String(), wantDOT)
+	}
+
+	s.calls["foo"] = append(s.calls["foo"], "error")
+	if g, err := WalkCallHierarchy(ctx, client, []CallHierarchyItem{callItem("main")}, &CallGraphOptions{Direction: CHDOutgoing}); err == nil {
+		t.Errorf("got %+v, want error", g)
+	}
+}
+
+func TestCallGraph_WriteDOT(t *testing.T) {
+	g := &CallGraph{
+		Nodes: []CallGraphNode{{Item: CallHierarchyItem{Name: "a\"b\\c\nd\te", URI: "file:///日本.go"}}},
+		Edges: []CallGraphEdge{{Caller: 0, Callee: 0}},
+	}
+	var b bytes.Buffer
+	if err := g.WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "digraph calls {\n\tn0 [label=\"a\\\"b\\\\c\\nd\te\", tooltip=\"file:///日本.go:1\"];\n\tn0 -> n0;\n}\n"
+	if b.String() != want {
+		t.Errorf("got DOT:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
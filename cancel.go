@@ -0,0 +1,124 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// maxEarlyCancels is the number of $/cancelRequest notifications for
+// unknown requests that a Canceller remembers.
+const maxEarlyCancels = 64
+
+// Canceller is a Handler that honors $/cancelRequest notifications for
+// the requests being handled by another Handler.
+//
+// Each request is handled with a context that is cancelled when a
+// $/cancelRequest notification with the request's ID is received.
+// Such a request fails with CodeRequestCancelled, whatever the result
+// of the other Handler. The $/cancelRequest notifications themselves
+// are not passed to the other Handler.
+//
+// Since a Conn handles requests concurrently, a $/cancelRequest
+// notification may be received before its request has started being
+// handled. The Canceller remembers the IDs of the last few
+// cancellations of unknown requests, and fails such requests
+// immediately.
+type Canceller struct {
+	h Handler
+
+	mu       sync.Mutex
+	inflight map[ID]*inflightRequest
+	early    map[ID]struct{} // cancelled IDs of requests not (yet) in flight
+	earlyIDs []ID            // the keys of early, oldest first
+}
+
+type inflightRequest struct {
+	cancel    context.CancelFunc
+	cancelled bool // whether the request was cancelled by Cancel
+}
+
+// NewCanceller returns a Canceller that passes requests and
+// notifications to h.
+func NewCanceller(h Handler) *Canceller {
+	return &Canceller{
+		h:        h,
+		inflight: map[ID]*inflightRequest{},
+		early:    map[ID]struct{}{},
+	}
+}
+
+// HandleRequest implements Handler.
+func (c *Canceller) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r := &inflightRequest{cancel: cancel}
+	c.mu.Lock()
+	if _, ok := c.early[req.ID]; ok {
+		delete(c.early, req.ID)
+		c.mu.Unlock()
+		return nil, errRequestCancelled(req.ID)
+	}
+	c.inflight[req.ID] = r
+	c.mu.Unlock()
+
+	result, err := c.h.HandleRequest(ctx, req)
+
+	c.mu.Lock()
+	if c.inflight[req.ID] == r {
+		delete(c.inflight, req.ID)
+	}
+	cancelled := r.cancelled
+	c.mu.Unlock()
+
+	if cancelled {
+		return nil, errRequestCancelled(req.ID)
+	}
+	return result, err
+}
+
+// HandleNotification implements Handler.
+func (c *Canceller) HandleNotification(ctx context.Context, n *Notification) error {
+	if n.Method != MethodCancelRequest {
+		return c.h.HandleNotification(ctx, n)
+	}
+	if n.Params == nil {
+		return NewError(CodeInvalidParams, "missing params")
+	}
+	var params CancelParams
+	if err := json.Unmarshal(*n.Params, &params); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %s", err)
+	}
+	c.Cancel(params.ID)
+	return nil
+}
+
+// Cancel cancels the request with the given ID, as if a
+// $/cancelRequest notification had been received for it. It reports
+// whether the request was in flight.
+func (c *Canceller) Cancel(id ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.inflight[id]; ok {
+		r.cancelled = true
+		r.cancel()
+		delete(c.inflight, id)
+		return true
+	}
+
+	if _, ok := c.early[id]; !ok {
+		if len(c.earlyIDs) == maxEarlyCancels {
+			delete(c.early, c.earlyIDs[0])
+			c.earlyIDs = c.earlyIDs[1:]
+		}
+		c.early[id] = struct{}{}
+		c.earlyIDs = append(c.earlyIDs, id)
+	}
+	return false
+}
+
+func errRequestCancelled(id ID) error {
+	return Errorf(CodeRequestCancelled, "request %s cancelled", id)
+}
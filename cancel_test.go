@@ -0,0 +1,149 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// waitingHandler handles requests by waiting for their context to be
+// done.
+type waitingHandler struct {
+	started chan ID
+	notifs  []string
+}
+
+func (h *waitingHandler) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	h.started <- req.ID
+	<-ctx.Done()
+	return "late result", nil
+}
+
+func (h *waitingHandler) HandleNotification(ctx context.Context, n *Notification) error {
+	h.notifs = append(h.notifs, n.Method)
+	return nil
+}
+
+func cancelNotification(t *testing.T, id ID) *Notification {
+	n := &Notification{Method: MethodCancelRequest}
+	if err := n.SetParams(CancelParams{ID: id}); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestCanceller(t *testing.T) {
+	h := &waitingHandler{started: make(chan ID)}
+	c := NewCanceller(h)
+	ctx := context.Background()
+
+	done := make(chan error)
+	for _, id := range []ID{IntID(1), StringID("2")} {
+		go func(id ID) {
+			_, err := c.HandleRequest(ctx, &Request{ID: id, Method: MethodReferences})
+			done <- err
+		}(id)
+		<-h.started
+	}
+
+	if err := c.HandleNotification(ctx, cancelNotification(t, StringID("2"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, CodeRequestCancelled) {
+		t.Errorf("got error %v, want code %s", err, CodeRequestCancelled)
+	}
+	if !c.Cancel(IntID(1)) {
+		t.Error("request 1 was not in flight")
+	}
+	if err := <-done; !errors.Is(err, CodeRequestCancelled) {
+		t.Errorf("got error %v, want code %s", err, CodeRequestCancelled)
+	}
+
+	if len(c.inflight) != 0 {
+		t.Errorf("got %d requests in flight after completion, want 0", len(c.inflight))
+	}
+
+	// Other notifications are passed through.
+	if err := c.HandleNotification(ctx, &Notification{Method: MethodDidOpen}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{MethodDidOpen}; !reflect.DeepEqual(h.notifs, want) {
+		t.Errorf("got notifications %q, want %q", h.notifs, want)
+	}
+
+	if err := c.HandleNotification(ctx, &Notification{Method: MethodCancelRequest, Params: rawMessage(`{"id":{}}`)}); !errors.Is(err, CodeInvalidParams) {
+		t.Errorf("got error %v, want code %s", err, CodeInvalidParams)
+	}
+}
+
+func TestCanceller_completed(t *testing.T) {
+	c := NewCanceller(&recordingHandler{})
+	for i := int64(0); i < 10; i++ {
+		if _, err := c.HandleRequest(context.Background(), &Request{ID: IntID(i), Method: MethodHover}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.inflight) != 0 {
+		t.Errorf("got %d requests in flight after completion, want 0", len(c.inflight))
+	}
+	if c.Cancel(IntID(1)) {
+		t.Error("completed request was in flight")
+	}
+}
+
+func TestCanceller_early(t *testing.T) {
+	h := &recordingHandler{}
+	c := NewCanceller(h)
+
+	// A cancellation received before its request is handled cancels
+	// the request immediately.
+	c.Cancel(IntID(1))
+	if _, err := c.HandleRequest(context.Background(), &Request{ID: IntID(1), Method: MethodHover}); !errors.Is(err, CodeRequestCancelled) {
+		t.Errorf("got error %v, want code %s", err, CodeRequestCancelled)
+	}
+	if len(h.handled) != 0 {
+		t.Errorf("got handled %q, want none", h.handled)
+	}
+
+	// Only the most recent cancellations of unknown requests are
+	// remembered.
+	for i := int64(0); i < 2*maxEarlyCancels; i++ {
+		c.Cancel(IntID(i))
+	}
+	if len(c.early) != maxEarlyCancels || len(c.earlyIDs) != maxEarlyCancels {
+		t.Errorf("got %d/%d early cancellations, want %d", len(c.early), len(c.earlyIDs), maxEarlyCancels)
+	}
+	if _, err := c.HandleRequest(context.Background(), &Request{ID: IntID(0), Method: MethodHover}); err != nil {
+		t.Errorf("got error %v for request whose cancellation was forgotten", err)
+	}
+}
+
+func TestCanceller_conn(t *testing.T) {
+	s := &blockingServer{started: make(chan struct{}), returned: make(chan struct{})}
+	a, b := net.Pipe()
+	serverConn := NewConn(context.Background(), a, NewCanceller(NewServerHandler(s)))
+	clientConn := NewConn(context.Background(), b, nil)
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- clientConn.Call(ctx, MethodDefinition, TextDocumentPositionParams{}, nil)
+	}()
+	<-s.started
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// The server stops handling the request.
+	select {
+	case <-s.returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not cancelled on the server")
+	}
+}
@@ -0,0 +1,29 @@
+package lsp
+
+import "context"
+
+// Caller sends requests and notifications to a JSON-RPC peer. *Conn
+// implements Caller.
+type Caller interface {
+	// Call sends a request with the given method and params (which
+	// may be nil) and waits for its response. If the response has a
+	// result and result is non-nil, the result is decoded into it. If
+	// the response has an error, it is returned as a *ResponseError.
+	Call(ctx context.Context, method string, params, result interface{}) error
+
+	// Notify sends a notification with the given method and params
+	// (which may be nil).
+	Notify(ctx context.Context, method string, params interface{}) error
+}
+
+// Client calls the methods of a language server, with one method per
+// request or notification sent from the client to the server.
+type Client struct {
+	caller Caller
+}
+
+// NewClient returns a Client that sends requests and notifications
+// using c.
+func NewClient(c Caller) *Client {
+	return &Client{caller: c}
+}
@@ -0,0 +1,320 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import "context"
+
+// Initialize calls the request "initialize".
+func (c *Client) Initialize(ctx context.Context, params InitializeParams) (*InitializeResult, error) {
+	var result *InitializeResult
+	if err := c.caller.Call(ctx, MethodInitialize, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Initialized sends the notification "initialized".
+func (c *Client) Initialized(ctx context.Context, params InitializedParams) error {
+	return c.caller.Notify(ctx, MethodInitialized, &params)
+}
+
+// Shutdown calls the request "shutdown".
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.caller.Call(ctx, MethodShutdown, nil, nil)
+}
+
+// Exit sends the notification "exit".
+func (c *Client) Exit(ctx context.Context) error {
+	return c.caller.Notify(ctx, MethodExit, nil)
+}
+
+// DidOpen sends the notification "textDocument/didOpen".
+func (c *Client) DidOpen(ctx context.Context, params DidOpenTextDocumentParams) error {
+	return c.caller.Notify(ctx, MethodDidOpen, &params)
+}
+
+// DidChange sends the notification "textDocument/didChange".
+func (c *Client) DidChange(ctx context.Context, params DidChangeTextDocumentParams) error {
+	return c.caller.Notify(ctx, MethodDidChange, &params)
+}
+
+// DidClose sends the notification "textDocument/didClose".
+func (c *Client) DidClose(ctx context.Context, params DidCloseTextDocumentParams) error {
+	return c.caller.Notify(ctx, MethodDidClose, &params)
+}
+
+// DidSave sends the notification "textDocument/didSave".
+func (c *Client) DidSave(ctx context.Context, params DidSaveTextDocumentParams) error {
+	return c.caller.Notify(ctx, MethodDidSave, &params)
+}
+
+// DidChangeConfiguration sends the notification "workspace/didChangeConfiguration".
+func (c *Client) DidChangeConfiguration(ctx context.Context, params DidChangeConfigurationParams) error {
+	return c.caller.Notify(ctx, MethodDidChangeConfiguration, &params)
+}
+
+// DidChangeWatchedFiles sends the notification "workspace/didChangeWatchedFiles".
+func (c *Client) DidChangeWatchedFiles(ctx context.Context, params DidChangeWatchedFilesParams) error {
+	return c.caller.Notify(ctx, MethodDidChangeWatchedFiles, &params)
+}
+
+// Completion calls the request "textDocument/completion".
+func (c *Client) Completion(ctx context.Context, params CompletionParams) (*CompletionList, error) {
+	var result *CompletionList
+	if err := c.caller.Call(ctx, MethodCompletion, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveCompletionItem calls the request "completionItem/resolve".
+func (c *Client) ResolveCompletionItem(ctx context.Context, params CompletionItem) (*CompletionItem, error) {
+	var result *CompletionItem
+	if err := c.caller.Call(ctx, MethodResolveCompletionItem, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Hover calls the request "textDocument/hover".
+func (c *Client) Hover(ctx context.Context, params TextDocumentPositionParams) (*Hover, error) {
+	var result *Hover
+	if err := c.caller.Call(ctx, MethodHover, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SignatureHelp calls the request "textDocument/signatureHelp".
+func (c *Client) SignatureHelp(ctx context.Context, params TextDocumentPositionParams) (*SignatureHelp, error) {
+	var result *SignatureHelp
+	if err := c.caller.Call(ctx, MethodSignatureHelp, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Definition calls the request "textDocument/definition".
+func (c *Client) Definition(ctx context.Context, params TextDocumentPositionParams) ([]Location, error) {
+	var result []Location
+	if err := c.caller.Call(ctx, MethodDefinition, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TypeDefinition calls the request "textDocument/typeDefinition".
+func (c *Client) TypeDefinition(ctx context.Context, params TextDocumentPositionParams) ([]Location, error) {
+	var result []Location
+	if err := c.caller.Call(ctx, MethodTypeDefinition, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Implementation calls the request "textDocument/implementation".
+func (c *Client) Implementation(ctx context.Context, params TextDocumentPositionParams) ([]Location, error) {
+	var result []Location
+	if err := c.caller.Call(ctx, MethodImplementation, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// References calls the request "textDocument/references".
+func (c *Client) References(ctx context.Context, params ReferenceParams) ([]Location, error) {
+	var result []Location
+	if err := c.caller.Call(ctx, MethodReferences, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DocumentHighlight calls the request "textDocument/documentHighlight".
+func (c *Client) DocumentHighlight(ctx context.Context, params TextDocumentPositionParams) ([]DocumentHighlight, error) {
+	var result []DocumentHighlight
+	if err := c.caller.Call(ctx, MethodDocumentHighlight, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DocumentSymbol calls the request "textDocument/documentSymbol".
+func (c *Client) DocumentSymbol(ctx context.Context, params DocumentSymbolParams) ([]SymbolInformation, error) {
+	var result []SymbolInformation
+	if err := c.caller.Call(ctx, MethodDocumentSymbol, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// WorkspaceSymbol calls the request "workspace/symbol".
+func (c *Client) WorkspaceSymbol(ctx context.Context, params WorkspaceSymbolParams) ([]SymbolInformation, error) {
+	var result []SymbolInformation
+	if err := c.caller.Call(ctx, MethodWorkspaceSymbol, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CodeAction calls the request "textDocument/codeAction".
+func (c *Client) CodeAction(ctx context.Context, params CodeActionParams) ([]CommandOrCodeAction, error) {
+	var result []CommandOrCodeAction
+	if err := c.caller.Call(ctx, MethodCodeAction, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveCodeAction calls the request "codeAction/resolve".
+func (c *Client) ResolveCodeAction(ctx context.Context, params CodeAction) (*CodeAction, error) {
+	var result *CodeAction
+	if err := c.caller.Call(ctx, MethodResolveCodeAction, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CodeLens calls the request "textDocument/codeLens".
+func (c *Client) CodeLens(ctx context.Context, params CodeLensParams) ([]CodeLens, error) {
+	var result []CodeLens
+	if err := c.caller.Call(ctx, MethodCodeLens, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ResolveCodeLens calls the request "codeLens/resolve".
+func (c *Client) ResolveCodeLens(ctx context.Context, params CodeLens) (*CodeLens, error) {
+	var result *CodeLens
+	if err := c.caller.Call(ctx, MethodResolveCodeLens, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Formatting calls the request "textDocument/formatting".
+func (c *Client) Formatting(ctx context.Context, params DocumentFormattingParams) ([]TextEdit, error) {
+	var result []TextEdit
+	if err := c.caller.Call(ctx, MethodFormatting, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RangeFormatting calls the request "textDocument/rangeFormatting".
+func (c *Client) RangeFormatting(ctx context.Context, params DocumentRangeFormattingParams) ([]TextEdit, error) {
+	var result []TextEdit
+	if err := c.caller.Call(ctx, MethodRangeFormatting, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OnTypeFormatting calls the request "textDocument/onTypeFormatting".
+func (c *Client) OnTypeFormatting(ctx context.Context, params DocumentOnTypeFormattingParams) ([]TextEdit, error) {
+	var result []TextEdit
+	if err := c.caller.Call(ctx, MethodOnTypeFormatting, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Rename calls the request "textDocument/rename".
+func (c *Client) Rename(ctx context.Context, params RenameParams) (*WorkspaceEdit, error) {
+	var result *WorkspaceEdit
+	if err := c.caller.Call(ctx, MethodRename, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SemanticTokensFull calls the request "textDocument/semanticTokens/full".
+func (c *Client) SemanticTokensFull(ctx context.Context, params SemanticTokensParams) (*SemanticTokens, error) {
+	var result *SemanticTokens
+	if err := c.caller.Call(ctx, MethodSemanticTokensFull, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SemanticTokensFullDelta calls the request "textDocument/semanticTokens/full/delta".
+func (c *Client) SemanticTokensFullDelta(ctx context.Context, params SemanticTokensDeltaParams) (*SemanticTokensOrDelta, error) {
+	var result *SemanticTokensOrDelta
+	if err := c.caller.Call(ctx, MethodSemanticTokensFullDelta, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SemanticTokensRange calls the request "textDocument/semanticTokens/range".
+func (c *Client) SemanticTokensRange(ctx context.Context, params SemanticTokensRangeParams) (*SemanticTokens, error) {
+	var result *SemanticTokens
+	if err := c.caller.Call(ctx, MethodSemanticTokensRange, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PrepareCallHierarchy calls the request "textDocument/prepareCallHierarchy".
+func (c *Client) PrepareCallHierarchy(ctx context.Context, params CallHierarchyPrepareParams) ([]CallHierarchyItem, error) {
+	var result []CallHierarchyItem
+	if err := c.caller.Call(ctx, MethodPrepareCallHierarchy, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IncomingCalls calls the request "callHierarchy/incomingCalls".
+func (c *Client) IncomingCalls(ctx context.Context, params CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, error) {
+	var result []CallHierarchyIncomingCall
+	if err := c.caller.Call(ctx, MethodIncomingCalls, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OutgoingCalls calls the request "callHierarchy/outgoingCalls".
+func (c *Client) OutgoingCalls(ctx context.Context, params CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, error) {
+	var result []CallHierarchyOutgoingCall
+	if err := c.caller.Call(ctx, MethodOutgoingCalls, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PrepareTypeHierarchy calls the request "textDocument/prepareTypeHierarchy".
+func (c *Client) PrepareTypeHierarchy(ctx context.Context, params TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error) {
+	var result []TypeHierarchyItem
+	if err := c.caller.Call(ctx, MethodPrepareTypeHierarchy, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Supertypes calls the request "typeHierarchy/supertypes".
+func (c *Client) Supertypes(ctx context.Context, params TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error) {
+	var result []TypeHierarchyItem
+	if err := c.caller.Call(ctx, MethodSupertypes, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Subtypes calls the request "typeHierarchy/subtypes".
+func (c *Client) Subtypes(ctx context.Context, params TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error) {
+	var result []TypeHierarchyItem
+	if err := c.caller.Call(ctx, MethodSubtypes, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExecuteCommand calls the request "workspace/executeCommand".
+func (c *Client) ExecuteCommand(ctx context.Context, params ExecuteCommandParams) (interface{}, error) {
+	var result interface{}
+	if err := c.caller.Call(ctx, MethodExecuteCommand, &params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
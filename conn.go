@@ -0,0 +1,393 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrClosed is returned when sending a message on a closed Conn, and
+// by calls that were pending when the Conn was closed.
+var ErrClosed = errors.New("lsp: connection is closed")
+
+// Conn is a JSON-RPC 2.0 connection over a stream of messages framed
+// using the LSP base protocol. It dispatches incoming requests and
+// notifications to a Handler, and implements Caller for outgoing ones.
+//
+// Notifications are handled one at a time, in the order in which they
+// are received. Requests are handled concurrently, each in its own
+// goroutine, after all notifications received before them. Handlers
+// can therefore rely on a textDocument/didChange notification having
+// been processed before a later request is handled, without a slow
+// request blocking the connection.
+//
+// Incoming messages are read continuously, independently of the
+// handler: responses are delivered to pending calls as soon as they
+// are read, and requests and notifications are queued for handling. A
+// handler (including a notification handler) can therefore call the
+// peer, for instance sending a workspace/configuration request from
+// the initialized notification.
+//
+// Messages in a JSON-RPC batch are handled individually, in order. The
+// responses to the requests of a batch, and to its invalid elements,
+// are sent together as a batch once all of them are ready; nothing is
+// sent in response to a batch of notifications.
+type Conn struct {
+	stream io.Closer
+	r      *MessageReader
+	w      *MessageWriter
+	h      Handler
+
+	ctx    context.Context // the context passed to the handler
+	cancel context.CancelFunc
+
+	onRecv  []func(Message)
+	onSend  []func(Message)
+	writeMu sync.Mutex
+
+	mu         sync.Mutex
+	seq        uint64
+	pending    map[ID]chan *Response
+	closed     bool
+	disconnect chan struct{}
+
+	// queue holds the received requests and notifications that have
+	// not been dispatched yet, and queued signals that it is not
+	// empty.
+	queueMu sync.Mutex
+	queue   []queuedMessage
+	queued  chan struct{}
+}
+
+// queuedMessage is a request or notification waiting to be handled,
+// with the responses of its batch if it was received in one.
+type queuedMessage struct {
+	msg   Message
+	batch *batchResponses
+}
+
+// batchResponses collects the responses to a batch, which are sent
+// together when the last one is added.
+type batchResponses struct {
+	mu        sync.Mutex
+	responses []Message
+	remaining int
+}
+
+// add adds a response to the batch, and returns all of its responses
+// if it was the last one.
+func (b *batchResponses) add(resp *Response) []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.responses = append(b.responses, resp)
+	b.remaining--
+	if b.remaining > 0 {
+		return nil
+	}
+	return b.responses
+}
+
+// ConnOpt is an option for NewConn.
+type ConnOpt func(*Conn)
+
+// OnRecv causes a Conn to call f with every message that it receives,
+// before handling the message.
+func OnRecv(f func(Message)) ConnOpt {
+	return func(c *Conn) { c.onRecv = append(c.onRecv, f) }
+}
+
+// OnSend causes a Conn to call f with every message that it sends,
+// before writing the message.
+func OnSend(f func(Message)) ConnOpt {
+	return func(c *Conn) { c.onSend = append(c.onSend, f) }
+}
+
+// NewConn returns a Conn that reads and writes messages on stream and
+// dispatches incoming requests and notifications to h (which may be
+// nil, in which case requests fail with CodeMethodNotFound and
+// notifications are ignored).
+//
+// The context passed to h is derived from ctx, and is cancelled when
+// the connection is closed.
+func NewConn(ctx context.Context, stream io.ReadWriteCloser, h Handler, opts ...ConnOpt) *Conn {
+	ctx, cancel := context.WithCancel(ctx)
+	c := &Conn{
+		stream:     stream,
+		r:          NewMessageReader(stream),
+		w:          NewMessageWriter(stream),
+		h:          h,
+		ctx:        ctx,
+		cancel:     cancel,
+		pending:    map[ID]chan *Response{},
+		disconnect: make(chan struct{}),
+		queued:     make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	go c.readMessages()
+	go c.dispatchMessages()
+	return c
+}
+
+// Call implements Caller. If ctx is done before the response is
+// received, Call sends a $/cancelRequest notification for the request
+// and returns ctx.Err().
+func (c *Conn) Call(ctx context.Context, method string, params, result interface{}) error {
+	req := &Request{Method: method}
+	if params != nil {
+		if err := req.SetParams(params); err != nil {
+			return err
+		}
+	}
+
+	ch := make(chan *Response, 1)
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.seq++
+	req.ID = ID{Num: c.seq}
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+
+	if err := c.send(req); err != nil {
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+		return err
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && resp.Result != nil {
+			return json.Unmarshal(*resp.Result, result)
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, req.ID)
+		c.mu.Unlock()
+		c.Notify(context.Background(), MethodCancelRequest, &CancelParams{ID: req.ID})
+		return ctx.Err()
+	case <-c.disconnect:
+		return ErrClosed
+	}
+}
+
+// Notify implements Caller.
+func (c *Conn) Notify(ctx context.Context, method string, params interface{}) error {
+	n := &Notification{Method: method}
+	if params != nil {
+		if err := n.SetParams(params); err != nil {
+			return err
+		}
+	}
+	return c.send(n)
+}
+
+// Close closes the connection and its underlying stream. Pending calls
+// fail with ErrClosed.
+func (c *Conn) Close() error {
+	return c.close()
+}
+
+// DisconnectNotify returns a channel that is closed when the
+// connection is closed, either by Close or because reading from the
+// underlying stream failed.
+func (c *Conn) DisconnectNotify() <-chan struct{} {
+	return c.disconnect
+}
+
+func (c *Conn) close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	c.pending = nil
+	close(c.disconnect)
+	c.mu.Unlock()
+
+	c.cancel()
+	return c.stream.Close()
+}
+
+func (c *Conn) send(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.write(data, msg)
+}
+
+// sendBatch sends msgs as a batch.
+func (c *Conn) sendBatch(msgs []Message) error {
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		return err
+	}
+	return c.write(data, msgs...)
+}
+
+// write writes the encoding data of msgs.
+func (c *Conn) write(data []byte, msgs ...Message) error {
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+	// Hold writeMu so that onSend observes messages in the order in
+	// which they are written.
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	for _, msg := range msgs {
+		for _, f := range c.onSend {
+			f(msg)
+		}
+	}
+	return c.w.WriteMessage(data)
+}
+
+func (c *Conn) readMessages() {
+	for {
+		data, err := c.r.ReadMessage()
+		if err != nil {
+			c.close()
+			return
+		}
+
+		msgs, batch, err := DecodeMessages(data)
+		errs, invalidElems := err.(BatchErrors)
+		if err != nil && !invalidElems {
+			c.send(&Response{ID: invalidMessageID(data), Error: decodeErrorResponse(err)})
+			continue
+		}
+
+		var b *batchResponses
+		if batch {
+			b = &batchResponses{remaining: len(errs)}
+			for _, msg := range msgs {
+				if _, ok := msg.(*Request); ok {
+					b.remaining++
+				}
+			}
+			if len(errs) > 0 {
+				var raws []json.RawMessage
+				json.Unmarshal(data, &raws) // already decoded by DecodeMessages
+				for _, e := range errs {
+					resp := &Response{ID: invalidMessageID(raws[e.Index]), Error: decodeErrorResponse(e.Err)}
+					if responses := b.add(resp); responses != nil {
+						c.sendBatch(responses)
+					}
+				}
+			}
+		}
+		for _, msg := range msgs {
+			for _, f := range c.onRecv {
+				f(msg)
+			}
+			if resp, ok := msg.(*Response); ok {
+				c.mu.Lock()
+				ch := c.pending[resp.ID]
+				delete(c.pending, resp.ID)
+				c.mu.Unlock()
+				if ch != nil {
+					ch <- resp
+				}
+				continue
+			}
+			c.queueMu.Lock()
+			c.queue = append(c.queue, queuedMessage{msg: msg, batch: b})
+			c.queueMu.Unlock()
+			select {
+			case c.queued <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// invalidMessageID returns the ID of an invalid message, for the
+// response to it: its id if it has a method (and so is a request, not
+// a response) and the id is valid, and the null ID otherwise.
+func invalidMessageID(data []byte) ID {
+	var msg struct {
+		ID     *ID              `json:"id"`
+		Method *json.RawMessage `json:"method"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil || msg.ID == nil || msg.Method == nil {
+		return NullID()
+	}
+	return *msg.ID
+}
+
+// decodeErrorResponse returns the error to send in response to a
+// message that could not be decoded: CodeParseError for invalid JSON,
+// and CodeInvalidRequest for JSON that is not a valid message.
+func decodeErrorResponse(err error) *ResponseError {
+	var re *ResponseError
+	if errors.As(err, &re) && (re.Code == CodeParseError || re.Code == CodeInvalidRequest) {
+		return re
+	}
+	return &ResponseError{Code: CodeInvalidRequest, Message: err.Error()}
+}
+
+// dispatchMessages handles the queued requests and notifications in
+// order, until the connection is closed.
+func (c *Conn) dispatchMessages() {
+	for {
+		select {
+		case <-c.queued:
+		case <-c.disconnect:
+			return
+		}
+		c.queueMu.Lock()
+		msgs := c.queue
+		c.queue = nil
+		c.queueMu.Unlock()
+
+		for _, m := range msgs {
+			switch msg := m.msg.(type) {
+			case *Request:
+				go c.handleRequest(msg, m.batch)
+			case *Notification:
+				if c.h != nil {
+					c.h.HandleNotification(c.ctx, msg)
+				}
+			}
+		}
+	}
+}
+
+// handleRequest handles req, and sends the response, or adds it to
+// batch if req was received in one.
+func (c *Conn) handleRequest(req *Request, batch *batchResponses) {
+	var result interface{}
+	err := errMethodNotFound(req.Method)
+	if c.h != nil {
+		result, err = c.h.HandleRequest(c.ctx, req)
+	}
+
+	resp := &Response{ID: req.ID}
+	if err == nil {
+		err = resp.SetResult(result)
+	}
+	if err != nil {
+		resp.Result = nil
+		resp.Error = ToResponseError(err)
+	}
+	if batch == nil {
+		c.send(resp)
+	} else if responses := batch.add(resp); responses != nil {
+		c.sendBatch(responses)
+	}
+}
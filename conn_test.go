@@ -0,0 +1,262 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type blockingServer struct {
+	testServer
+	started  chan struct{}
+	returned chan struct{} // if non-nil, closed when Definition returns
+}
+
+func (s *blockingServer) Definition(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error) {
+	close(s.started)
+	<-ctx.Done()
+	if s.returned != nil {
+		close(s.returned)
+	}
+	return nil, ctx.Err()
+}
+
+func newTestConns(t *testing.T, s LanguageServer) (client *Client, clientConn, serverConn *Conn) {
+	a, b := net.Pipe()
+	ctx := context.Background()
+	serverConn = NewConn(ctx, a, NewServerHandler(s))
+	clientConn = NewConn(ctx, b, nil)
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+	})
+	return NewClient(clientConn), clientConn, serverConn
+}
+
+func TestClient(t *testing.T) {
+	s := &testServer{}
+	client, _, _ := newTestConns(t, s)
+	ctx := context.Background()
+
+	if err := client.DidOpen(ctx, DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: "file:///a"}}); err != nil {
+		t.Fatal(err)
+	}
+	hover, err := client.Hover(ctx, TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///a"},
+		Position:     Position{Line: 3, Character: 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Hover{Contents: []MarkedString{RawMarkedString("3:4")}}); !reflect.DeepEqual(hover, want) {
+		t.Errorf("got %+v, want %+v", hover, want)
+	}
+	// Notifications are handled before requests received after them.
+	if want := []DocumentURI{"file:///a"}; !reflect.DeepEqual(s.opened, want) {
+		t.Errorf("got opened %v, want %v", s.opened, want)
+	}
+
+	hover, err = client.Hover(ctx, TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: "file:///none"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hover != nil {
+		t.Errorf("got %+v, want nil", hover)
+	}
+
+	if _, err := client.References(ctx, ReferenceParams{}); !errors.Is(err, CodeMethodNotFound) {
+		t.Errorf("got error %v, want code %s", err, CodeMethodNotFound)
+	}
+}
+
+// configServer requests the client's configuration when it is
+// initialized.
+type configServer struct {
+	UnimplementedLanguageServer
+	conn   *Conn
+	config chan ConfigurationResult
+	err    chan error
+}
+
+func (s *configServer) Initialized(ctx context.Context, params *InitializedParams) error {
+	var result ConfigurationResult
+	err := s.conn.Call(ctx, MethodWorkspaceConfiguration, &ConfigurationParams{Items: []ConfigurationItem{{Section: "go"}}}, &result)
+	if err != nil {
+		s.err <- err
+		return err
+	}
+	s.config <- result
+	return nil
+}
+
+// configClient answers workspace/configuration requests.
+type configClient struct{}
+
+func (configClient) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	if req.Method != MethodWorkspaceConfiguration {
+		return nil, errMethodNotFound(req.Method)
+	}
+	return ConfigurationResult{map[string]interface{}{"gofmt": true}}, nil
+}
+
+func (configClient) HandleNotification(ctx context.Context, n *Notification) error {
+	return nil
+}
+
+// TestConn_callFromNotification checks that a notification handler
+// can call the peer, which requires responses to be read while the
+// handler runs.
+func TestConn_callFromNotification(t *testing.T) {
+	a, b := net.Pipe()
+	ctx := context.Background()
+	s := &configServer{config: make(chan ConfigurationResult, 1), err: make(chan error, 1)}
+	s.conn = NewConn(ctx, a, NewServerHandler(s))
+	clientConn := NewConn(ctx, b, configClient{})
+	t.Cleanup(func() {
+		clientConn.Close()
+		s.conn.Close()
+	})
+
+	if err := NewClient(clientConn).Initialized(ctx, InitializedParams{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case config := <-s.config:
+		if want := (ConfigurationResult{map[string]interface{}{"gofmt": true}}); !reflect.DeepEqual(config, want) {
+			t.Errorf("got %v, want %v", config, want)
+		}
+	case err := <-s.err:
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("workspace/configuration call from the initialized notification did not return")
+	}
+}
+
+func TestConn_invalidMessage(t *testing.T) {
+	a, b := net.Pipe()
+	conn := NewConn(context.Background(), a, nil)
+	t.Cleanup(func() { conn.Close() })
+	r, w := NewMessageReader(b), NewMessageWriter(b)
+
+	tests := []struct {
+		data   string
+		wantID ID
+		want   ErrorCode
+	}{
+		{data: `{"jsonrpc":"2.0","method":`, wantID: NullID(), want: CodeParseError},
+		{data: `[{"jsonrpc":"2.0"`, wantID: NullID(), want: CodeParseError},
+		{data: `5`, wantID: NullID(), want: CodeInvalidRequest},
+		{data: `{"jsonrpc":"2.0"}`, wantID: NullID(), want: CodeInvalidRequest},
+		{data: `{"jsonrpc":"1.0","id":7,"method":"x"}`, wantID: IntID(7), want: CodeInvalidRequest},
+		{data: `{"jsonrpc":"2.0","id":{},"method":"x"}`, wantID: NullID(), want: CodeInvalidRequest},
+		{data: `[]`, wantID: NullID(), want: CodeInvalidRequest},
+	}
+	for _, test := range tests {
+		go w.WriteMessage([]byte(test.data))
+		data, err := r.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := DecodeMessage(data)
+		if err != nil {
+			t.Fatalf("%s: %s", test.data, err)
+		}
+		resp, ok := msg.(*Response)
+		if !ok || resp.ID != test.wantID || resp.Error == nil {
+			t.Errorf("%s: got %s, want an error response with id %s", test.data, data, test.wantID)
+			continue
+		}
+		if resp.Error.Code != test.want {
+			t.Errorf("%s: got code %s, want %s", test.data, resp.Error.Code, test.want)
+		}
+	}
+
+	// The valid elements of a batch are handled despite the invalid
+	// ones, and all the responses are sent in one batch.
+	go w.WriteMessage([]byte(`[{"jsonrpc":"2.0","id":1,"method":"a"},{"jsonrpc":"1.0","id":2,"method":"b"},{"jsonrpc":"2.0","method":"c"},5]`))
+	data, err := r.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, batch, err := DecodeMessages(data)
+	if err != nil || !batch {
+		t.Fatalf("got %s, want a batch of responses", data)
+	}
+	codes := map[ID]ErrorCode{}
+	for _, msg := range msgs {
+		if resp, ok := msg.(*Response); ok && resp.Error != nil {
+			codes[resp.ID] = resp.Error.Code
+		}
+	}
+	want := map[ID]ErrorCode{IntID(1): CodeMethodNotFound, IntID(2): CodeInvalidRequest, NullID(): CodeInvalidRequest}
+	if len(msgs) != len(want) || !reflect.DeepEqual(codes, want) {
+		t.Errorf("got %s, want error responses %v", data, want)
+	}
+
+	// Nothing is sent in response to a batch of notifications.
+	go func() {
+		w.WriteMessage([]byte(`[{"jsonrpc":"2.0","method":"c"}]`))
+		w.WriteMessage([]byte(`{"jsonrpc":"2.0","id":3,"method":"d"}`))
+	}()
+	data, err = r.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, ok := decodeResponse(data); !ok || resp.ID != IntID(3) {
+		t.Errorf("got %s, want the response to request 3", data)
+	}
+}
+
+func TestConn_cancel(t *testing.T) {
+	s := &blockingServer{started: make(chan struct{})}
+	client, _, _ := newTestConns(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.started
+		cancel()
+	}()
+	if _, err := client.Definition(ctx, TextDocumentPositionParams{}); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestConn_close(t *testing.T) {
+	s := &blockingServer{started: make(chan struct{})}
+	client, clientConn, serverConn := newTestConns(t, s)
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Definition(context.Background(), TextDocumentPositionParams{})
+		done <- err
+	}()
+	<-s.started
+	serverConn.Close()
+
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("got error %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending call did not fail after the connection was closed")
+	}
+	<-clientConn.DisconnectNotify()
+
+	if err := client.Shutdown(context.Background()); err != ErrClosed {
+		t.Errorf("got error %v, want %v", err, ErrClosed)
+	}
+}
+
+// decodeResponse decodes a response, reporting whether data is one.
+func decodeResponse(data []byte) (*Response, bool) {
+	msg, err := DecodeMessage(data)
+	if err != nil {
+		return nil, false
+	}
+	resp, ok := msg.(*Response)
+	return resp, ok
+}
@@ -0,0 +1,292 @@
+package lsp
+
+import "unicode/utf8"
+
+// DefaultMaxDiffDistance is the edit distance searched for by
+// ComputeTextEdits when the MaxDistance field of DiffOptions is zero.
+const DefaultMaxDiffDistance = 1000
+
+// DiffOptions controls ComputeTextEdits.
+type DiffOptions struct {
+	// Encoding is the encoding of the positions in the returned edits.
+	// If empty, UTF-16 is used.
+	Encoding PositionEncodingKind
+
+	// Refine causes each block of changed lines to be diffed again
+	// character by character, so that the edits touch only the
+	// characters that changed instead of whole lines.
+	Refine bool
+
+	// MaxDistance is the maximum number of inserted and deleted lines
+	// (or characters, when refining) for which an optimal diff is
+	// searched. Beyond it, the whole region between the common prefix
+	// and suffix is replaced. If zero, DefaultMaxDiffDistance is used.
+	MaxDistance int
+}
+
+// ComputeTextEdits returns edits that transform oldText into newText,
+// with positions referring to oldText. Applying the edits to oldText
+// (as with ApplyTextEditsEncoding) yields exactly newText.
+//
+// The edits are computed by a line-level diff (Myers' algorithm), so
+// that unchanged lines are not touched, and are sorted and
+// non-overlapping. Edits never split a "\r\n" line terminator or a
+// UTF-8 sequence. If opts is nil, the defaults described in
+// DiffOptions are used.
+func ComputeTextEdits(oldText, newText string, opts *DiffOptions) []TextEdit {
+	if oldText == newText {
+		return nil
+	}
+	if opts == nil {
+		opts = &DiffOptions{}
+	}
+	max := opts.MaxDistance
+	if max <= 0 {
+		max = DefaultMaxDiffDistance
+	}
+
+	var spans []diffSpan
+	a, b := splitTokens(oldText, lineTokenLen), splitTokens(newText, lineTokenLen)
+	for _, s := range diffTokens(a, b, max) {
+		if !opts.Refine {
+			spans = append(spans, s)
+			continue
+		}
+		ca := splitTokens(oldText[s.oldStart:s.oldEnd], charTokenLen)
+		cb := splitTokens(s.newText, charTokenLen)
+		for _, c := range diffTokens(ca, cb, max) {
+			c.oldStart += s.oldStart
+			c.oldEnd += s.oldStart
+			spans = append(spans, c)
+		}
+	}
+
+	x := NewLineIndex(oldText, opts.Encoding)
+	edits := make([]TextEdit, len(spans))
+	for i, s := range spans {
+		// Spans start and end on token boundaries, which are valid
+		// positions.
+		start, _ := x.Position(s.oldStart)
+		end, _ := x.Position(s.oldEnd)
+		edits[i] = TextEdit{Range: Range{Start: start, End: end}, NewText: s.newText}
+	}
+	return edits
+}
+
+// diffSpan replaces the bytes [oldStart, oldEnd) of the old text with
+// newText.
+type diffSpan struct {
+	oldStart, oldEnd int
+	newText          string
+}
+
+// splitTokens splits text into consecutive tokens, whose lengths are
+// given by tokenLen.
+func splitTokens(text string, tokenLen func(string) int) []string {
+	var tokens []string
+	for len(text) > 0 {
+		n := tokenLen(text)
+		tokens = append(tokens, text[:n])
+		text = text[n:]
+	}
+	return tokens
+}
+
+// lineTokenLen returns the length of the first line of text, including
+// its terminator.
+func lineTokenLen(text string) int {
+	if n := lineTerminator(text, 0); n != -1 {
+		return n
+	}
+	return len(text)
+}
+
+// charTokenLen returns the length of the first character of text,
+// treating "\r\n" as a single character.
+func charTokenLen(text string) int {
+	if len(text) >= 2 && text[0] == '\r' && text[1] == '\n' {
+		return 2
+	}
+	_, size := utf8.DecodeRuneInString(text)
+	return size
+}
+
+// diffTokens returns the spans of bytes to replace to transform the
+// concatenation of a into the concatenation of b, with offsets relative
+// to the start of a. If more than max tokens would need to be inserted
+// or deleted, the whole region between the common prefix and suffix is
+// replaced.
+func diffTokens(a, b []string, max int) []diffSpan {
+	// Trim the common prefix and suffix, which is cheap and makes the
+	// quadratic part of the algorithm operate on the changed region
+	// only.
+	prefix, prefixLen := 0, 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefixLen += len(a[prefix])
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+
+	hunks, ok := myers(a, b, max)
+	if !ok {
+		hunks = []diffHunk{{a1: len(a), b1: len(b)}}
+	}
+
+	// Convert token indices to byte offsets.
+	aOff := tokenOffsets(a, prefixLen)
+	bOff := tokenOffsets(b, 0)
+	newText := concat(b)
+	spans := make([]diffSpan, len(hunks))
+	for i, h := range hunks {
+		spans[i] = diffSpan{
+			oldStart: aOff[h.a0],
+			oldEnd:   aOff[h.a1],
+			newText:  newText[bOff[h.b0]:bOff[h.b1]],
+		}
+	}
+	return spans
+}
+
+// tokenOffsets returns the offsets of the start of each token, plus
+// that of the end of the last one, starting at base.
+func tokenOffsets(tokens []string, base int) []int {
+	offs := make([]int, len(tokens)+1)
+	offs[0] = base
+	for i, t := range tokens {
+		offs[i+1] = offs[i] + len(t)
+	}
+	return offs
+}
+
+func concat(tokens []string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	n := 0
+	for _, t := range tokens {
+		n += len(t)
+	}
+	b := make([]byte, 0, n)
+	for _, t := range tokens {
+		b = append(b, t...)
+	}
+	return string(b)
+}
+
+// diffHunk replaces the tokens a[a0:a1] with b[b0:b1].
+type diffHunk struct {
+	a0, a1, b0, b1 int
+}
+
+// myers returns the hunks of a shortest edit script transforming a into
+// b, as computed by the algorithm in Eugene W. Myers, "An O(ND)
+// Difference Algorithm and Its Variations" (1986). It reports false if
+// the script would insert or delete more than max tokens.
+func myers(a, b []string, max int) ([]diffHunk, bool) {
+	n, m := len(a), len(b)
+	if max > n+m {
+		max = n + m
+	}
+
+	// v[off+k] is the furthest x reached on diagonal k = x-y; trace[d]
+	// is a copy of v after d edits, used to recover the path.
+	off := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1] // down: insert b[y-1]
+			} else {
+				x = v[off+k-1] + 1 // right: delete a[x-1]
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v...))
+				return myersHunks(trace, off, n, m), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v...))
+	}
+	return nil, false
+}
+
+// myersHunks walks back the path recorded by myers from (n, m) to (0,
+// 0), and returns the hunks along it in order.
+func myersHunks(trace [][]int, off, n, m int) []diffHunk {
+	const (
+		opEqual = iota
+		opDelete
+		opInsert
+	)
+	var ops []byte // in reverse order
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d-1]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, opEqual)
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, opInsert)
+		} else {
+			ops = append(ops, opDelete)
+		}
+		x, y = prevX, prevY
+	}
+	// The remaining snake of the first step consists of equal tokens,
+	// which need no hunk.
+
+	var hunks []diffHunk
+	var cur *diffHunk
+	for i := len(ops) - 1; i >= 0; i-- {
+		switch ops[i] {
+		case opEqual:
+			if cur != nil {
+				hunks = append(hunks, *cur)
+				cur = nil
+			}
+			x++
+			y++
+			continue
+		case opDelete:
+			if cur == nil {
+				cur = &diffHunk{a0: x, a1: x, b0: y, b1: y}
+			}
+			x++
+		case opInsert:
+			if cur == nil {
+				cur = &diffHunk{a0: x, a1: x, b0: y, b1: y}
+			}
+			y++
+		}
+		cur.a1, cur.b1 = x, y
+	}
+	if cur != nil {
+		hunks = append(hunks, *cur)
+	}
+	return hunks
+}
@@ -0,0 +1,107 @@
+package lsp
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComputeTextEdits(t *testing.T) {
+	tests := []struct {
+		old, new string
+		refine   bool
+		want     []TextEdit
+	}{
+		{old: "a\nb\n", new: "a\nb\n", want: nil},
+		{old: "", new: "a\n", want: []TextEdit{edit(0, 0, 0, 0, "a\n")}},
+		{old: "a\n", new: "", want: []TextEdit{edit(0, 0, 1, 0, "")}},
+		{old: "a\nb\nc\n", new: "a\nx\nc\n", want: []TextEdit{edit(1, 0, 2, 0, "x\n")}},
+		{old: "a\nb\nc\nd\n", new: "x\nb\nc\ny\nd\n", want: []TextEdit{edit(0, 0, 1, 0, "x\n"), edit(3, 0, 3, 0, "y\n")}},
+		{old: "func f() {\n\treturn 1\n}\n", new: "func f() {\n\treturn 2\n}\n", refine: true, want: []TextEdit{edit(1, 8, 1, 9, "2")}},
+		{old: "x😀y\n", new: "x😁y\n", refine: true, want: []TextEdit{edit(0, 1, 0, 3, "😁")}},
+		{old: "a\r\nb", new: "a\nb", refine: true, want: []TextEdit{edit(0, 1, 1, 0, "\n")}},
+	}
+	for _, test := range tests {
+		got := ComputeTextEdits(test.old, test.new, &DiffOptions{Refine: test.refine})
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q -> %q: got %v, want %v", test.old, test.new, got, test.want)
+		}
+	}
+}
+
+func TestComputeTextEdits_MaxDistance(t *testing.T) {
+	old, new := "a\nb\nc\nd\ne\n", "a\nB\nc\nD\ne\n"
+	got := ComputeTextEdits(old, new, &DiffOptions{MaxDistance: 2})
+	if want := []TextEdit{edit(1, 0, 4, 0, "B\nc\nD\n")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestComputeTextEdits_random checks that applying the computed edits
+// reproduces the new text exactly.
+func TestComputeTextEdits_random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c", "\n", "\r", "\r\n", "😀", "日"}
+	randomText := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+	for i := 0; i < 500; i++ {
+		old := randomText(rng.Intn(40))
+		new := []byte(old)
+		for j := rng.Intn(4); j >= 0; j-- {
+			p := rng.Intn(len(new) + 1)
+			q := p + rng.Intn(len(new)-p+1)
+			new = append(new[:p:p], append([]byte(randomText(rng.Intn(4))), new[q:]...)...)
+		}
+		for _, opts := range []DiffOptions{
+			{},
+			{Refine: true},
+			{Refine: true, Encoding: PEKUTF8},
+			{Refine: true, Encoding: PEKUTF32, MaxDistance: 3},
+		} {
+			opts := opts
+			edits := ComputeTextEdits(old, string(new), &opts)
+			got, err := ApplyTextEditsEncoding(old, edits, opts.Encoding)
+			if err != nil {
+				t.Fatalf("%q -> %q (%+v): %v: %s", old, new, opts, edits, err)
+			}
+			if got != string(new) {
+				t.Fatalf("%q -> %q (%+v): %v: got %q", old, new, opts, edits, got)
+			}
+		}
+	}
+}
+
+// editDocument returns text with every 100th line modified.
+func editDocument(text string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i += 100 {
+		lines[i] = "// edited " + lines[i]
+	}
+	return strings.Join(lines, "")
+}
+
+func BenchmarkComputeTextEdits(b *testing.B) {
+	old := generateDocument(1 << 20)
+	new := editDocument(old)
+	b.SetBytes(int64(len(old)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ComputeTextEdits(old, new, nil)
+	}
+}
+
+func BenchmarkComputeTextEdits_Refine(b *testing.B) {
+	old := generateDocument(1 << 20)
+	new := editDocument(old)
+	b.SetBytes(int64(len(old)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ComputeTextEdits(old, new, &DiffOptions{Refine: true})
+	}
+}
@@ -4,3 +4,5 @@
 // See https://github.com/Microsoft/language-server-protocol/blob/master/protocol.md
 // for more information.
 package lsp
+
+//go:generate go run gen.go
@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultMaxSnapshots is the number of snapshots retained per document
+// by a DocumentStore whose MaxSnapshots field is zero.
+const DefaultMaxSnapshots = 16
+
+// ErrDocumentNotOpen is returned (wrapped) by a DocumentStore for
+// operations on a document that is not open.
+var ErrDocumentNotOpen = errors.New("document is not open")
+
+// Snapshot is the immutable state of an open text document at a
+// given version.
+type Snapshot struct {
+	URI        DocumentURI
+	LanguageID string
+	Version    int
+	Text       string
+
+	index LineIndex
+}
+
+// LineIndex returns a LineIndex for the text of the snapshot, for
+// converting between positions and offsets.
+func (s *Snapshot) LineIndex() *LineIndex {
+	x := s.index // LineIndex.Apply never modifies the shared text or lines
+	return &x
+}
+
+// DocumentStore holds the text of the documents open in a client, as
+// described by textDocument/didOpen, didChange and didClose
+// notifications. It is safe for concurrent use.
+//
+// A server typically calls DidOpen, DidChange and DidClose from the
+// corresponding LanguageServer methods, and Get or Snapshot from its
+// request handlers.
+type DocumentStore struct {
+	// SyncKind is the TextDocumentSyncKind that the server advertised.
+	// With TDSKFull, changes with a Range are rejected; with TDSKNone,
+	// all changes are rejected.
+	SyncKind TextDocumentSyncKind
+
+	// Encoding is the negotiated position encoding. If empty, UTF-16
+	// is used.
+	Encoding PositionEncodingKind
+
+	// MaxSnapshots is the number of versions of each document retained
+	// for Snapshot. If zero, DefaultMaxSnapshots is used.
+	MaxSnapshots int
+
+	mu   sync.RWMutex
+	docs map[DocumentURI][]*Snapshot // oldest first
+}
+
+// NewDocumentStore returns an empty DocumentStore for a server using
+// the given sync kind and position encoding.
+func NewDocumentStore(kind TextDocumentSyncKind, enc PositionEncodingKind) *DocumentStore {
+	return &DocumentStore{SyncKind: kind, Encoding: enc}
+}
+
+// DidOpen records a document opened by the client. It is an error for
+// the document to be open already.
+func (s *DocumentStore) DidOpen(params *DidOpenTextDocumentParams) error {
+	item := params.TextDocument
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[item.URI]; ok {
+		return fmt.Errorf("lsp: didOpen %s: document is already open", item.URI)
+	}
+	if s.docs == nil {
+		s.docs = map[DocumentURI][]*Snapshot{}
+	}
+	s.docs[item.URI] = []*Snapshot{{
+		URI:        item.URI,
+		LanguageID: item.LanguageID,
+		Version:    item.Version,
+		Text:       item.Text,
+		index:      *NewLineIndex(item.Text, s.Encoding),
+	}}
+	return nil
+}
+
+// DidChange applies the content changes of a didChange notification,
+// in order, to an open document, creating a new snapshot. Each change
+// copies the text of the document (see LineIndex.Apply), so it takes
+// time linear in the size of the document.
+//
+// It is an error for the document not to be open, for the new version
+// not to be greater than the current one, for a change not to be
+// allowed by SyncKind, or for a change's range to be invalid or out of
+// bounds. If an error is returned, the document is left unchanged.
+func (s *DocumentStore) DidChange(params *DidChangeTextDocumentParams) error {
+	uri, version := params.TextDocument.URI, params.TextDocument.Version
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	history, ok := s.docs[uri]
+	if !ok {
+		return fmt.Errorf("lsp: didChange %s: %w", uri, ErrDocumentNotOpen)
+	}
+	cur := history[len(history)-1]
+	if version <= cur.Version {
+		return fmt.Errorf("lsp: didChange %s: version %d is not greater than current version %d", uri, version, cur.Version)
+	}
+
+	x := cur.index
+	for i, change := range params.ContentChanges {
+		switch {
+		case s.SyncKind == TDSKNone:
+			return fmt.Errorf("lsp: didChange %s: document sync is disabled", uri)
+		case s.SyncKind == TDSKFull && change.Range != nil:
+			return fmt.Errorf("lsp: didChange %s: change %d has a range, but document sync is full", uri, i)
+		}
+		if err := x.Apply(change); err != nil {
+			return fmt.Errorf("lsp: didChange %s: change %d: %s", uri, i, err)
+		}
+	}
+
+	max := s.MaxSnapshots
+	if max <= 0 {
+		max = DefaultMaxSnapshots
+	}
+	if len(history) >= max {
+		history = append(history[:0:0], history[len(history)-max+1:]...)
+	}
+	s.docs[uri] = append(history, &Snapshot{
+		URI:        uri,
+		LanguageID: cur.LanguageID,
+		Version:    version,
+		Text:       x.Text(),
+		index:      x,
+	})
+	return nil
+}
+
+// DidClose forgets a document closed by the client, together with all
+// of its snapshots.
+func (s *DocumentStore) DidClose(params *DidCloseTextDocumentParams) error {
+	uri := params.TextDocument.URI
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[uri]; !ok {
+		return fmt.Errorf("lsp: didClose %s: %w", uri, ErrDocumentNotOpen)
+	}
+	delete(s.docs, uri)
+	return nil
+}
+
+// Get returns the latest snapshot of an open document, and reports
+// whether the document is open.
+func (s *DocumentStore) Get(uri DocumentURI) (*Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history, ok := s.docs[uri]
+	if !ok {
+		return nil, false
+	}
+	return history[len(history)-1], true
+}
+
+// Snapshot returns the snapshot of an open document at the given
+// version. It is an error for the version to be unknown or to have
+// been discarded because of MaxSnapshots.
+func (s *DocumentStore) Snapshot(uri DocumentURI, version int) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	history, ok := s.docs[uri]
+	if !ok {
+		return nil, fmt.Errorf("lsp: %s: %w", uri, ErrDocumentNotOpen)
+	}
+	i := sort.Search(len(history), func(i int) bool { return history[i].Version >= version })
+	if i == len(history) || history[i].Version != version {
+		return nil, fmt.Errorf("lsp: %s: no snapshot for version %d (have versions %d to %d)", uri, version, history[0].Version, history[len(history)-1].Version)
+	}
+	return history[i], nil
+}
+
+// URIs returns the URIs of the open documents, in sorted order.
+func (s *DocumentStore) URIs() []DocumentURI {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uris := make([]DocumentURI, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	return uris
+}
@@ -0,0 +1,171 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDocumentStore(t *testing.T) {
+	const uri = DocumentURI("file:///a.go")
+	s := NewDocumentStore(TDSKIncremental, PEKUTF16)
+	if err := s.DidOpen(&DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: "package a\n"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DidOpen(&DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Version: 1}}); err == nil {
+		t.Error("got nil error for reopening a document")
+	}
+
+	change := func(version int, changes ...TextDocumentContentChangeEvent) error {
+		return s.DidChange(&DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: uri}, Version: version},
+			ContentChanges: changes,
+		})
+	}
+	if err := change(2,
+		TextDocumentContentChangeEvent{Range: &Range{Start: Position{1, 0}, End: Position{1, 0}}, Text: "func f() {}\n"},
+		TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 8}, End: Position{0, 9}}, Text: "b"},
+	); err != nil {
+		t.Fatal(err)
+	}
+	snap, ok := s.Get(uri)
+	if !ok {
+		t.Fatal("document is not open")
+	}
+	if want := (Snapshot{URI: uri, LanguageID: "go", Version: 2, Text: "package b\nfunc f() {}\n"}); snap.URI != want.URI || snap.LanguageID != want.LanguageID || snap.Version != want.Version || snap.Text != want.Text {
+		t.Errorf("got %+v, want %+v", snap, want)
+	}
+	if pos, err := snap.LineIndex().Position(len("package b\nfunc")); err != nil || pos != (Position{1, 4}) {
+		t.Errorf("got position %s (error %v), want 1:4", pos, err)
+	}
+
+	for _, test := range []struct {
+		version int
+		change  TextDocumentContentChangeEvent
+	}{
+		{version: 2, change: TextDocumentContentChangeEvent{Text: "x"}},
+		{version: 1, change: TextDocumentContentChangeEvent{Text: "x"}},
+		{version: 3, change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{5, 0}, End: Position{5, 0}}}},
+		{version: 3, change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 2}, End: Position{0, 1}}}},
+	} {
+		if err := change(test.version, test.change); err == nil {
+			t.Errorf("version %d, %+v: got nil error", test.version, test.change)
+		}
+	}
+	if snap, _ := s.Get(uri); snap.Version != 2 {
+		t.Errorf("got version %d after failed changes, want 2", snap.Version)
+	}
+
+	if err := change(5, TextDocumentContentChangeEvent{Text: "package c\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if snap, err := s.Snapshot(uri, 1); err != nil || snap.Text != "package a\n" {
+		t.Errorf("got %+v (error %v), want version 1", snap, err)
+	}
+	if _, err := s.Snapshot(uri, 3); err == nil {
+		t.Error("got nil error for unknown version")
+	}
+
+	if err := s.DidClose(&DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get(uri); ok {
+		t.Error("document is still open after didClose")
+	}
+	if err := change(6, TextDocumentContentChangeEvent{Text: "x"}); !errors.Is(err, ErrDocumentNotOpen) {
+		t.Errorf("got error %v, want ErrDocumentNotOpen", err)
+	}
+	if err := s.DidClose(&DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}}); !errors.Is(err, ErrDocumentNotOpen) {
+		t.Errorf("got error %v, want ErrDocumentNotOpen", err)
+	}
+}
+
+func TestDocumentStore_SyncKind(t *testing.T) {
+	tests := []struct {
+		kind    TextDocumentSyncKind
+		ranged  bool
+		wantErr bool
+	}{
+		{kind: TDSKNone, ranged: false, wantErr: true},
+		{kind: TDSKFull, ranged: false, wantErr: false},
+		{kind: TDSKFull, ranged: true, wantErr: true},
+		{kind: TDSKIncremental, ranged: true, wantErr: false},
+	}
+	for _, test := range tests {
+		s := NewDocumentStore(test.kind, "")
+		if err := s.DidOpen(&DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: "file:///a", Version: 1, Text: "abc"}}); err != nil {
+			t.Fatal(err)
+		}
+		change := TextDocumentContentChangeEvent{Text: "x"}
+		if test.ranged {
+			change.Range = &Range{Start: Position{0, 0}, End: Position{0, 1}}
+		}
+		err := s.DidChange(&DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///a"}, Version: 2},
+			ContentChanges: []TextDocumentContentChangeEvent{change},
+		})
+		if (err != nil) != test.wantErr {
+			t.Errorf("kind %d, ranged %v: got error %v, want error %v", test.kind, test.ranged, err, test.wantErr)
+		}
+	}
+}
+
+func TestDocumentStore_MaxSnapshots(t *testing.T) {
+	s := &DocumentStore{SyncKind: TDSKFull, MaxSnapshots: 3}
+	if err := s.DidOpen(&DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: "file:///a", Version: 0}}); err != nil {
+		t.Fatal(err)
+	}
+	for v := 1; v < 10; v++ {
+		if err := s.DidChange(&DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///a"}, Version: v},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: fmt.Sprint(v)}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var versions []int
+	for v := 0; v < 10; v++ {
+		if _, err := s.Snapshot("file:///a", v); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	if want := []int{7, 8, 9}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("got versions %v, want %v", versions, want)
+	}
+}
+
+func TestDocumentStore_concurrent(t *testing.T) {
+	s := NewDocumentStore(TDSKIncremental, PEKUTF16)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		uri := DocumentURI(fmt.Sprintf("file:///%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.DidOpen(&DidOpenTextDocumentParams{TextDocument: TextDocumentItem{URI: uri, Version: 0}}); err != nil {
+				t.Error(err)
+				return
+			}
+			for v := 1; v <= 50; v++ {
+				end := Position{Line: v - 1}
+				if err := s.DidChange(&DidChangeTextDocumentParams{
+					TextDocument:   VersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: uri}, Version: v},
+					ContentChanges: []TextDocumentContentChangeEvent{{Range: &Range{Start: end, End: end}, Text: "x\n"}},
+				}); err != nil {
+					t.Error(err)
+					return
+				}
+				s.URIs()
+				s.Get(uri)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, uri := range s.URIs() {
+		if snap, _ := s.Get(uri); snap.LineIndex().LineCount() != 51 {
+			t.Errorf("%s: got %d lines, want 51", uri, snap.LineIndex().LineCount())
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ApplyTextEdits applies edits, whose positions are expressed in
+// UTF-16 code units, to text. See ApplyTextEditsEncoding.
+func ApplyTextEdits(text string, edits []TextEdit) (string, error) {
+	return ApplyTextEditsEncoding(text, edits, PEKUTF16)
+}
+
+// ApplyTextEditsEncoding applies edits to text, with their positions
+// expressed in the given encoding.
+//
+// As the specification requires, the ranges of all edits refer to the
+// original text, and must not overlap. Inserts at the same position
+// are applied in the order in which they appear in edits, and before
+// any edit replacing text that starts at that position.
+func ApplyTextEditsEncoding(text string, edits []TextEdit, enc PositionEncodingKind) (string, error) {
+	type span struct {
+		start, end int
+		edit       int // index in edits
+	}
+	x := NewLineIndex(text, enc)
+	spans := make([]span, len(edits))
+	for i, e := range edits {
+		start, err := x.Offset(e.Range.Start)
+		if err != nil {
+			return "", fmt.Errorf("lsp: edit %d: %s", i, err)
+		}
+		end, err := x.Offset(e.Range.End)
+		if err != nil {
+			return "", fmt.Errorf("lsp: edit %d: %s", i, err)
+		}
+		if end < start {
+			return "", fmt.Errorf("lsp: edit %d: invalid range %s: end is before start", i, e.Range)
+		}
+		spans[i] = span{start: start, end: end, edit: i}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end < spans[j].end
+	})
+
+	var b strings.Builder
+	last := 0
+	for i, s := range spans {
+		if s.start < last {
+			prev := spans[i-1].edit
+			return "", fmt.Errorf("lsp: edit %d (range %s) overlaps edit %d (range %s)", s.edit, edits[s.edit].Range, prev, edits[prev].Range)
+		}
+		b.WriteString(text[last:s.start])
+		b.WriteString(edits[s.edit].NewText)
+		last = s.end
+	}
+	b.WriteString(text[last:])
+	return b.String(), nil
+}
+
+// FileStore is a collection of files that a WorkspaceEdit can be
+// applied to, such as a directory on disk or an in-memory map.
+type FileStore interface {
+	// ReadFile returns the contents of the file with the given URI.
+	ReadFile(uri DocumentURI) (string, error)
+
+	// WriteFile replaces the contents of the file with the given URI.
+	WriteFile(uri DocumentURI, text string) error
+}
+
+// ResourceFileStore is a FileStore that also supports the resource
+// operations of (WorkspaceEdit).DocumentChanges, honoring their
+// options.
+type ResourceFileStore interface {
+	FileStore
+	CreateFile(op *CreateFile) error
+	RenameFile(op *RenameFile) error
+	DeleteFile(op *DeleteFile) error
+}
+
+// VersionedFileStore is a FileStore that knows the versions of the
+// documents open in a client, against which the versions in
+// TextDocumentEdits are checked.
+type VersionedFileStore interface {
+	FileStore
+
+	// Version returns the version of the document with the given URI,
+	// and false if the version is unknown (for instance, if the
+	// document is not open).
+	Version(uri DocumentURI) (int, bool)
+}
+
+// ApplyWorkspaceEdit applies a WorkspaceEdit, with its positions
+// expressed in the given encoding (or UTF-16, if enc is empty), to the
+// files in fs.
+//
+// If edit.DocumentChanges is set, it is used instead of edit.Changes, as
+// clients do. Its entries are applied in order, stopping at the first
+// error; resource operations require fs to be a ResourceFileStore, and
+// versioned TextDocumentEdits are rejected if fs is a VersionedFileStore
+// that knows a different version of the document.
+//
+// Otherwise, the edits to all files in edit.Changes are computed before
+// any file is written, so that an invalid edit leaves fs unchanged.
+// Files are written in order of their URIs.
+func ApplyWorkspaceEdit(fs FileStore, edit *WorkspaceEdit, enc PositionEncodingKind) error {
+	if len(edit.DocumentChanges) > 0 {
+		return applyDocumentChanges(fs, edit.DocumentChanges, enc)
+	}
+
+	uris := make([]string, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	results := make([]string, len(uris))
+	for i, uri := range uris {
+		text, err := fs.ReadFile(DocumentURI(uri))
+		if err != nil {
+			return err
+		}
+		results[i], err = ApplyTextEditsEncoding(text, edit.Changes[uri], enc)
+		if err != nil {
+			return fmt.Errorf("%s (in %s)", err, uri)
+		}
+	}
+	for i, uri := range uris {
+		if err := fs.WriteFile(DocumentURI(uri), results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyDocumentChanges(fs FileStore, changes []DocumentChange, enc PositionEncodingKind) error {
+	rfs, _ := fs.(ResourceFileStore)
+	for i, c := range changes {
+		if c.TextDocumentEdit == nil && rfs == nil {
+			return fmt.Errorf("lsp: document change %d is a resource operation, which %T does not support", i, fs)
+		}
+	}
+
+	for i, c := range changes {
+		var err error
+		switch {
+		case c.TextDocumentEdit != nil:
+			err = applyTextDocumentEdit(fs, c.TextDocumentEdit, enc)
+		case c.CreateFile != nil:
+			err = rfs.CreateFile(c.CreateFile)
+		case c.RenameFile != nil:
+			err = rfs.RenameFile(c.RenameFile)
+		case c.DeleteFile != nil:
+			err = rfs.DeleteFile(c.DeleteFile)
+		default:
+			err = fmt.Errorf("lsp: empty document change")
+		}
+		if err != nil {
+			return fmt.Errorf("%s (in document change %d)", err, i)
+		}
+	}
+	return nil
+}
+
+func applyTextDocumentEdit(fs FileStore, e *TextDocumentEdit, enc PositionEncodingKind) error {
+	uri := e.TextDocument.URI
+	if vfs, ok := fs.(VersionedFileStore); ok && e.TextDocument.Version != nil {
+		if v, ok := vfs.Version(uri); ok && v != *e.TextDocument.Version {
+			return fmt.Errorf("lsp: edit of %s is for version %d, but the document is at version %d", uri, *e.TextDocument.Version, v)
+		}
+	}
+	text, err := fs.ReadFile(uri)
+	if err != nil {
+		return err
+	}
+	edits := make([]TextEdit, len(e.Edits))
+	for i, ae := range e.Edits {
+		edits[i] = ae.TextEdit
+	}
+	if text, err = ApplyTextEditsEncoding(text, edits, enc); err != nil {
+		return fmt.Errorf("%s (in %s)", err, uri)
+	}
+	return fs.WriteFile(uri, text)
+}
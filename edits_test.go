@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func edit(startLine, startChar, endLine, endChar int, newText string) TextEdit {
+	return TextEdit{Range: Range{Start: Position{startLine, startChar}, End: Position{endLine, endChar}}, NewText: newText}
+}
+
+func TestApplyTextEdits(t *testing.T) {
+	tests := []struct {
+		text  string
+		edits []TextEdit
+		want  string
+	}{
+		{text: "abc", edits: nil, want: "abc"},
+		{text: "abc\ndef", edits: []TextEdit{edit(1, 0, 1, 3, "DEF"), edit(0, 0, 0, 1, "A")}, want: "Abc\nDEF"},
+		{text: "abc", edits: []TextEdit{edit(0, 1, 0, 1, "1"), edit(0, 1, 0, 1, "2"), edit(0, 1, 0, 1, "3")}, want: "a123bc"},
+		{text: "abc", edits: []TextEdit{edit(0, 1, 0, 2, "X"), edit(0, 1, 0, 1, "1")}, want: "a1Xc"},
+		{text: "abc", edits: []TextEdit{edit(0, 0, 0, 1, ""), edit(0, 1, 0, 2, "")}, want: "c"},
+		{text: "a\nb\nc", edits: []TextEdit{edit(0, 1, 2, 0, " ")}, want: "a c"},
+		{text: "x😀y", edits: []TextEdit{edit(0, 1, 0, 3, "")}, want: "xy"},
+		{text: "abc", edits: []TextEdit{edit(0, 3, 0, 3, "\n")}, want: "abc\n"},
+	}
+	for _, test := range tests {
+		got, err := ApplyTextEdits(test.text, test.edits)
+		if err != nil {
+			t.Errorf("%q %v: %s", test.text, test.edits, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q %v: got %q, want %q", test.text, test.edits, got, test.want)
+		}
+	}
+
+	for _, edits := range [][]TextEdit{
+		{edit(0, 0, 0, 2, "x"), edit(0, 1, 0, 3, "y")},
+		{edit(0, 1, 0, 2, "x"), edit(0, 1, 0, 2, "y")},
+		{edit(0, 0, 0, 3, "x"), edit(0, 1, 0, 1, "y")},
+		{edit(0, 2, 0, 1, "x")},
+		{edit(2, 0, 2, 0, "x")},
+		{edit(0, 1, 0, 2, "")}, // inside the surrogate pair
+	} {
+		if got, err := ApplyTextEdits("😀bc", edits); err == nil {
+			t.Errorf("%v: got %q, want error", edits, got)
+		}
+	}
+}
+
+func TestApplyTextEditsEncoding(t *testing.T) {
+	got, err := ApplyTextEditsEncoding("日😀x", []TextEdit{edit(0, 7, 0, 8, "y")}, PEKUTF8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "日😀y"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+type mapFileStore map[DocumentURI]string
+
+func (m mapFileStore) ReadFile(uri DocumentURI) (string, error) {
+	text, ok := m[uri]
+	if !ok {
+		return "", fmt.Errorf("%s: file does not exist", uri)
+	}
+	return text, nil
+}
+
+func (m mapFileStore) WriteFile(uri DocumentURI, text string) error {
+	m[uri] = text
+	return nil
+}
+
+func TestApplyWorkspaceEdit(t *testing.T) {
+	fs := mapFileStore{"file:///a": "func f() {}\n", "file:///b": "f()\nf()\n"}
+	err := ApplyWorkspaceEdit(fs, &WorkspaceEdit{Changes: map[string][]TextEdit{
+		"file:///a": {edit(0, 5, 0, 6, "g")},
+		"file:///b": {edit(0, 0, 0, 1, "g"), edit(1, 0, 1, 1, "g")},
+	}}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (mapFileStore{"file:///a": "func g() {}\n", "file:///b": "g()\ng()\n"}); !reflect.DeepEqual(fs, want) {
+		t.Errorf("got %v, want %v", fs, want)
+	}
+
+	// An invalid edit to one file leaves all files unchanged.
+	err = ApplyWorkspaceEdit(fs, &WorkspaceEdit{Changes: map[string][]TextEdit{
+		"file:///a": {edit(0, 0, 0, 4, "fn")},
+		"file:///b": {edit(0, 0, 0, 2, "x"), edit(0, 1, 0, 3, "y")},
+	}}, PEKUTF16)
+	if err == nil {
+		t.Fatal("got nil error for overlapping edits")
+	}
+	if fs["file:///a"] != "func g() {}\n" {
+		t.Errorf("file:///a was modified: %q", fs["file:///a"])
+	}
+
+	if err := ApplyWorkspaceEdit(fs, &WorkspaceEdit{Changes: map[string][]TextEdit{"file:///c": nil}}, ""); err == nil {
+		t.Error("got nil error for missing file")
+	}
+}
+
+type resourceFileStore struct {
+	mapFileStore
+	versions map[DocumentURI]int
+}
+
+func (fs resourceFileStore) Version(uri DocumentURI) (int, bool) {
+	v, ok := fs.versions[uri]
+	return v, ok
+}
+
+func (fs resourceFileStore) CreateFile(op *CreateFile) error {
+	if _, ok := fs.mapFileStore[op.URI]; ok && (op.Options == nil || !op.Options.Overwrite) {
+		return fmt.Errorf("%s: file exists", op.URI)
+	}
+	fs.mapFileStore[op.URI] = ""
+	return nil
+}
+
+func (fs resourceFileStore) RenameFile(op *RenameFile) error {
+	text, ok := fs.mapFileStore[op.OldURI]
+	if !ok {
+		return fmt.Errorf("%s: file does not exist", op.OldURI)
+	}
+	delete(fs.mapFileStore, op.OldURI)
+	fs.mapFileStore[op.NewURI] = text
+	return nil
+}
+
+func (fs resourceFileStore) DeleteFile(op *DeleteFile) error {
+	delete(fs.mapFileStore, op.URI)
+	return nil
+}
+
+func TestApplyWorkspaceEdit_DocumentChanges(t *testing.T) {
+	version := func(v int) *int { return &v }
+	textEdit := func(uri DocumentURI, v *int, edits ...TextEdit) DocumentChange {
+		e := &TextDocumentEdit{TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: uri}, Version: v}}
+		for _, te := range edits {
+			e.Edits = append(e.Edits, AnnotatedTextEdit{TextEdit: te})
+		}
+		return DocumentChange{TextDocumentEdit: e}
+	}
+
+	fs := resourceFileStore{
+		mapFileStore: mapFileStore{"file:///a.go": "package a\n", "file:///old.go": "x"},
+		versions:     map[DocumentURI]int{"file:///a.go": 4},
+	}
+	err := ApplyWorkspaceEdit(fs, &WorkspaceEdit{
+		// Ignored in favor of DocumentChanges.
+		Changes: map[string][]TextEdit{"file:///a.go": {edit(0, 0, 0, 7, "")}},
+		DocumentChanges: []DocumentChange{
+			textEdit("file:///a.go", version(4), edit(0, 8, 0, 9, "b")),
+			{CreateFile: &CreateFile{URI: "file:///b.go"}},
+			textEdit("file:///b.go", nil, edit(0, 0, 0, 0, "package b\n")),
+			{RenameFile: &RenameFile{OldURI: "file:///a.go", NewURI: "file:///c.go"}},
+			{DeleteFile: &DeleteFile{URI: "file:///old.go"}},
+		},
+	}, PEKUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (mapFileStore{"file:///b.go": "package b\n", "file:///c.go": "package b\n"}); !reflect.DeepEqual(fs.mapFileStore, want) {
+		t.Errorf("got %v, want %v", fs.mapFileStore, want)
+	}
+
+	// Edits of a different version of a document are rejected.
+	fs.versions["file:///c.go"] = 1
+	err = ApplyWorkspaceEdit(fs, &WorkspaceEdit{DocumentChanges: []DocumentChange{textEdit("file:///c.go", version(2), edit(0, 0, 0, 0, "x"))}}, "")
+	if err == nil {
+		t.Error("got nil error for an edit of a different version")
+	}
+
+	// Resource operations need a ResourceFileStore.
+	err = ApplyWorkspaceEdit(fs.mapFileStore, &WorkspaceEdit{DocumentChanges: []DocumentChange{
+		textEdit("file:///c.go", nil, edit(0, 0, 0, 0, "x")),
+		{DeleteFile: &DeleteFile{URI: "file:///c.go"}},
+	}}, "")
+	if err == nil {
+		t.Error("got nil error for a resource operation on a FileStore")
+	}
+	if fs.mapFileStore["file:///c.go"] != "package b\n" {
+		t.Errorf("file:///c.go was modified: %q", fs.mapFileStore["file:///c.go"])
+	}
+}
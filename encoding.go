@@ -0,0 +1,213 @@
+package lsp
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// PositionEncodingKind is the encoding in which the Character field
+// of a Position is expressed, negotiated during initialize with the
+// general.positionEncodings client capability and the
+// positionEncoding server capability. If none is negotiated, UTF-16 is
+// used.
+type PositionEncodingKind string
+
+const (
+	// PEKUTF8 counts characters in UTF-8 code units (bytes).
+	PEKUTF8 PositionEncodingKind = "utf-8"
+
+	// PEKUTF16 counts characters in UTF-16 code units. It is the
+	// default, and must be supported by all servers.
+	PEKUTF16 PositionEncodingKind = "utf-16"
+
+	// PEKUTF32 counts characters in UTF-32 code units (Unicode code
+	// points).
+	PEKUTF32 PositionEncodingKind = "utf-32"
+)
+
+// NegotiatePositionEncoding returns the position encoding that a
+// server supporting the given encodings should use with a client that
+// advertises the given general.positionEncodings capability: the
+// first encoding preferred by the client that the server supports, or
+// PEKUTF16 if there is none.
+func NegotiatePositionEncoding(client []PositionEncodingKind, supported ...PositionEncodingKind) PositionEncodingKind {
+	for _, c := range client {
+		for _, s := range supported {
+			if c == s {
+				return c
+			}
+		}
+	}
+	return PEKUTF16
+}
+
+// PositionToOffset returns the byte offset in text of a position whose
+// Character is expressed in the given encoding (or UTF-16, if enc is
+// empty).
+//
+// Lines may be terminated by "\n", "\r\n" or "\r". As the
+// specification requires, a Character greater than the length of its
+// line refers to the end of the line. It is an error for the line not
+// to exist, or for the position to fall inside a character.
+func PositionToOffset(text string, pos Position, enc PositionEncodingKind) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("lsp: invalid position %s", pos)
+	}
+	start := 0
+	for line := 0; line < pos.Line; line++ {
+		n := lineTerminator(text, start)
+		if n == -1 {
+			return 0, fmt.Errorf("lsp: position %s is beyond the last line (%d)", pos, line)
+		}
+		start = n
+	}
+	end := lineEnd(text, start)
+	off, err := columnToOffset(text[start:end], pos.Character, enc)
+	if err != nil {
+		return 0, fmt.Errorf("lsp: position %s: %s", pos, err)
+	}
+	return start + off, nil
+}
+
+// OffsetToPosition returns the position of a byte offset in text, with
+// its Character expressed in the given encoding (or UTF-16, if enc is
+// empty). It is an error for the offset to be outside of text or
+// inside a character.
+func OffsetToPosition(text string, offset int, enc PositionEncodingKind) (Position, error) {
+	if offset < 0 || offset > len(text) {
+		return Position{}, fmt.Errorf("lsp: offset %d is out of range [0, %d]", offset, len(text))
+	}
+	var pos Position
+	start := 0
+	for {
+		n := lineTerminator(text, start)
+		if n == -1 || n > offset {
+			break
+		}
+		start = n
+		pos.Line++
+	}
+	col, err := offsetToColumn(text[start:], offset-start, enc)
+	if err != nil {
+		return Position{}, fmt.Errorf("lsp: offset %d: %s", offset, err)
+	}
+	pos.Character = col
+	return pos, nil
+}
+
+// PositionToRuneOffset returns the offset in runes (Unicode code
+// points) in text of a position whose Character is expressed in the
+// given encoding.
+func PositionToRuneOffset(text string, pos Position, enc PositionEncodingKind) (int, error) {
+	off, err := PositionToOffset(text, pos, enc)
+	if err != nil {
+		return 0, err
+	}
+	return utf8.RuneCountInString(text[:off]), nil
+}
+
+// RuneOffsetToPosition returns the position of an offset in runes
+// (Unicode code points) in text, with its Character expressed in the
+// given encoding.
+func RuneOffsetToPosition(text string, runeOffset int, enc PositionEncodingKind) (Position, error) {
+	if runeOffset < 0 {
+		return Position{}, fmt.Errorf("lsp: rune offset %d is out of range", runeOffset)
+	}
+	off := 0
+	for i := 0; i < runeOffset; i++ {
+		if off == len(text) {
+			return Position{}, fmt.Errorf("lsp: rune offset %d is out of range", runeOffset)
+		}
+		_, size := utf8.DecodeRuneInString(text[off:])
+		off += size
+	}
+	return OffsetToPosition(text, off, enc)
+}
+
+// ConvertPosition converts a position in text from one encoding to
+// another.
+func ConvertPosition(text string, pos Position, from, to PositionEncodingKind) (Position, error) {
+	off, err := PositionToOffset(text, pos, from)
+	if err != nil {
+		return Position{}, err
+	}
+	return OffsetToPosition(text, off, to)
+}
+
+// lineTerminator returns the offset of the start of the line after the
+// one containing offset start, or -1 if that is the last line.
+func lineTerminator(text string, start int) int {
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\n':
+			return i + 1
+		case '\r':
+			if i+1 < len(text) && text[i+1] == '\n' {
+				return i + 2
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// lineEnd returns the offset of the line terminator (or of the end of
+// text) of the line containing offset start.
+func lineEnd(text string, start int) int {
+	for i := start; i < len(text); i++ {
+		if text[i] == '\n' || text[i] == '\r' {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// unitLen returns the number of code units of the given encoding
+// needed to encode the rune r, which was decoded from size bytes.
+// Invalid UTF-8 bytes count as one code unit.
+func unitLen(r rune, size int, enc PositionEncodingKind) int {
+	switch enc {
+	case PEKUTF8:
+		return size
+	case PEKUTF32:
+		return 1
+	}
+	if r >= 0x10000 {
+		return 2 // surrogate pair
+	}
+	return 1
+}
+
+// columnToOffset returns the byte offset in line (which does not
+// include its terminator) of a column expressed in code units of the
+// given encoding. Columns beyond the end of line refer to its end.
+func columnToOffset(line string, col int, enc PositionEncodingKind) (int, error) {
+	units := 0
+	for off := 0; off < len(line); {
+		if units == col {
+			return off, nil
+		}
+		r, size := utf8.DecodeRuneInString(line[off:])
+		units += unitLen(r, size, enc)
+		if units > col {
+			return 0, fmt.Errorf("character %d is inside a character", col)
+		}
+		off += size
+	}
+	return len(line), nil
+}
+
+// offsetToColumn returns the column, expressed in code units of the
+// given encoding, of the byte offset off in line.
+func offsetToColumn(line string, off int, enc PositionEncodingKind) (int, error) {
+	units := 0
+	for i := 0; i < off; {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if i+size > off {
+			return 0, fmt.Errorf("byte offset %d is inside a character", off)
+		}
+		units += unitLen(r, size, enc)
+		i += size
+	}
+	return units, nil
+}
@@ -0,0 +1,134 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPositionToOffset(t *testing.T) {
+	// "😀" is 4 bytes, 2 UTF-16 code units and 1 code point; "日" is 3
+	// bytes, 1 UTF-16 code unit and 1 code point.
+	const text = "a😀b\r\n日本\rx\n\n"
+
+	tests := []struct {
+		pos    Position
+		enc    PositionEncodingKind
+		offset int
+	}{
+		{pos: Position{0, 0}, enc: PEKUTF16, offset: 0},
+		{pos: Position{0, 1}, enc: PEKUTF16, offset: 1},
+		{pos: Position{0, 3}, enc: PEKUTF16, offset: 5},
+		{pos: Position{0, 3}, enc: "", offset: 5},
+		{pos: Position{0, 2}, enc: PEKUTF32, offset: 5},
+		{pos: Position{0, 5}, enc: PEKUTF8, offset: 5},
+		{pos: Position{0, 4}, enc: PEKUTF16, offset: 6},
+		{pos: Position{0, 100}, enc: PEKUTF16, offset: 6}, // clamped to the end of the line
+		{pos: Position{1, 0}, enc: PEKUTF16, offset: 8},
+		{pos: Position{1, 1}, enc: PEKUTF16, offset: 11},
+		{pos: Position{1, 1}, enc: PEKUTF32, offset: 11},
+		{pos: Position{1, 3}, enc: PEKUTF8, offset: 11},
+		{pos: Position{1, 2}, enc: PEKUTF16, offset: 14},
+		{pos: Position{2, 0}, enc: PEKUTF16, offset: 15},
+		{pos: Position{2, 1}, enc: PEKUTF16, offset: 16},
+		{pos: Position{3, 0}, enc: PEKUTF16, offset: 17},
+		{pos: Position{4, 0}, enc: PEKUTF16, offset: 18},
+	}
+	for _, test := range tests {
+		offset, err := PositionToOffset(text, test.pos, test.enc)
+		if err != nil {
+			t.Errorf("%s (%s): %s", test.pos, test.enc, err)
+			continue
+		}
+		if offset != test.offset {
+			t.Errorf("%s (%s): got offset %d, want %d", test.pos, test.enc, offset, test.offset)
+		}
+
+		if test.pos.Character == 100 {
+			continue
+		}
+		pos, err := OffsetToPosition(text, test.offset, test.enc)
+		if err != nil {
+			t.Errorf("offset %d (%s): %s", test.offset, test.enc, err)
+			continue
+		}
+		if pos != test.pos {
+			t.Errorf("offset %d (%s): got position %s, want %s", test.offset, test.enc, pos, test.pos)
+		}
+	}
+
+	for _, test := range []struct {
+		pos Position
+		enc PositionEncodingKind
+	}{
+		{pos: Position{0, 2}, enc: PEKUTF16}, // inside the surrogate pair
+		{pos: Position{0, 3}, enc: PEKUTF8},  // inside the UTF-8 sequence
+		{pos: Position{5, 0}, enc: PEKUTF16},
+		{pos: Position{-1, 0}, enc: PEKUTF16},
+	} {
+		if offset, err := PositionToOffset(text, test.pos, test.enc); err == nil {
+			t.Errorf("%s (%s): got offset %d, want error", test.pos, test.enc, offset)
+		}
+	}
+	for _, offset := range []int{-1, 2, 9, 19} {
+		if pos, err := OffsetToPosition(text, offset, PEKUTF16); err == nil {
+			t.Errorf("offset %d: got position %s, want error", offset, pos)
+		}
+	}
+}
+
+func TestRuneOffset(t *testing.T) {
+	const text = "😀\n日本語😀x"
+	pos, err := RuneOffsetToPosition(text, 6, PEKUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Position{1, 5}); pos != want {
+		t.Errorf("got %s, want %s", pos, want)
+	}
+	n, err := PositionToRuneOffset(text, pos, PEKUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 6 {
+		t.Errorf("got rune offset %d, want 6", n)
+	}
+	if _, err := RuneOffsetToPosition(text, 8, PEKUTF16); err == nil {
+		t.Error("got nil error for rune offset out of range")
+	}
+}
+
+func TestConvertPosition(t *testing.T) {
+	const text = "x := \"😀日\" // comment"
+	pos, err := ConvertPosition(text, Position{0, 9}, PEKUTF16, PEKUTF8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Position{0, 13}); pos != want {
+		t.Errorf("got %s, want %s", pos, want)
+	}
+}
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	tests := []struct {
+		client    []PositionEncodingKind
+		supported []PositionEncodingKind
+		want      PositionEncodingKind
+	}{
+		{client: nil, supported: []PositionEncodingKind{PEKUTF8}, want: PEKUTF16},
+		{client: []PositionEncodingKind{PEKUTF32, PEKUTF8}, supported: []PositionEncodingKind{PEKUTF8, PEKUTF32}, want: PEKUTF32},
+		{client: []PositionEncodingKind{PEKUTF8}, supported: []PositionEncodingKind{PEKUTF16}, want: PEKUTF16},
+	}
+	for _, test := range tests {
+		if got := NegotiatePositionEncoding(test.client, test.supported...); got != test.want {
+			t.Errorf("%v, %v: got %q, want %q", test.client, test.supported, got, test.want)
+		}
+	}
+
+	var params InitializeParams
+	if err := json.Unmarshal([]byte(`{"capabilities":{"general":{"positionEncodings":["utf-8","utf-16"]}}}`), &params); err != nil {
+		t.Fatal(err)
+	}
+	if got := NegotiatePositionEncoding(params.Capabilities.General.PositionEncodings, PEKUTF8); got != PEKUTF8 {
+		t.Errorf("got %q, want %q", got, PEKUTF8)
+	}
+}
@@ -0,0 +1,153 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrorCode is a JSON-RPC 2.0 or LSP error code.
+//
+// ErrorCode implements error so that the codes can be used as targets
+// of errors.Is:
+//
+//	if errors.Is(err, lsp.CodeContentModified) {
+//		// retry
+//	}
+type ErrorCode int64
+
+// The error codes defined by JSON-RPC 2.0 and LSP.
+const (
+	CodeParseError     ErrorCode = -32700
+	CodeInvalidRequest ErrorCode = -32600
+	CodeMethodNotFound ErrorCode = -32601
+	CodeInvalidParams  ErrorCode = -32602
+	CodeInternalError  ErrorCode = -32603
+
+	// CodeServerNotInitialized is returned for requests received
+	// before the initialize request.
+	CodeServerNotInitialized ErrorCode = -32002
+	CodeUnknownErrorCode     ErrorCode = -32001
+
+	// CodeRequestFailed is returned when a request is syntactically
+	// and semantically correct but the server failed to fulfil it.
+	CodeRequestFailed ErrorCode = -32803
+
+	// CodeServerCancelled is returned when the server cancelled a
+	// request. The client may retry it.
+	CodeServerCancelled ErrorCode = -32802
+
+	// CodeContentModified is returned when the content of a document
+	// changed in a way that invalidated the result of a request.
+	CodeContentModified ErrorCode = -32801
+
+	// CodeRequestCancelled is returned when the client cancelled a
+	// request with $/cancelRequest.
+	CodeRequestCancelled ErrorCode = -32800
+)
+
+func (c ErrorCode) String() string {
+	if s, ok := errorCodeName[c]; ok {
+		return s
+	}
+	return "ErrorCode(" + strconv.FormatInt(int64(c), 10) + ")"
+}
+
+// Error implements error.
+func (c ErrorCode) Error() string {
+	return c.String()
+}
+
+var errorCodeName = map[ErrorCode]string{
+	CodeParseError:           "ParseError",
+	CodeInvalidRequest:       "InvalidRequest",
+	CodeMethodNotFound:       "MethodNotFound",
+	CodeInvalidParams:        "InvalidParams",
+	CodeInternalError:        "InternalError",
+	CodeServerNotInitialized: "ServerNotInitialized",
+	CodeUnknownErrorCode:     "UnknownErrorCode",
+	CodeRequestFailed:        "RequestFailed",
+	CodeServerCancelled:      "ServerCancelled",
+	CodeContentModified:      "ContentModified",
+	CodeRequestCancelled:     "RequestCancelled",
+}
+
+// ResponseError is the error object of a JSON-RPC 2.0 response. It
+// implements error, and errors.Is reports whether it has the code of
+// a target ErrorCode or *ResponseError.
+type ResponseError struct {
+	Code    ErrorCode        `json:"code"`
+	Message string           `json:"message"`
+	Data    *json.RawMessage `json:"data,omitempty"`
+}
+
+// NewError returns a *ResponseError with the given code and message.
+func NewError(code ErrorCode, message string) *ResponseError {
+	return &ResponseError{Code: code, Message: message}
+}
+
+// Errorf returns a *ResponseError with the given code and a message
+// formatted according to a format specifier.
+func Errorf(code ErrorCode, format string, args ...interface{}) *ResponseError {
+	return &ResponseError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("lsp: %s: %s", e.Code, e.Message)
+}
+
+// Is reports whether e has the code of target, which must be an
+// ErrorCode or a *ResponseError.
+func (e *ResponseError) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrorCode:
+		return e.Code == t
+	case *ResponseError:
+		return t != nil && e.Code == t.Code
+	}
+	return false
+}
+
+// SetData sets e.Data to the JSON encoding of v, such as an
+// InitializeError.
+func (e *ResponseError) SetData(v interface{}) error {
+	raw, err := marshalRaw(v)
+	e.Data = raw
+	return err
+}
+
+// DecodeData decodes e.Data into v. It leaves v unchanged if e has no
+// data.
+func (e *ResponseError) DecodeData(v interface{}) error {
+	if e.Data == nil {
+		return nil
+	}
+	return json.Unmarshal(*e.Data, v)
+}
+
+// ToResponseError converts err to the *ResponseError that should be
+// sent to the peer in reply to a failed request:
+//
+//   - a *ResponseError anywhere in err's chain is returned as is;
+//   - an ErrorCode yields an error with that code;
+//   - context.Canceled yields CodeRequestCancelled;
+//   - any other error yields CodeInternalError.
+func ToResponseError(err error) *ResponseError {
+	if err == nil {
+		return nil
+	}
+	var re *ResponseError
+	if errors.As(err, &re) {
+		return re
+	}
+	var code ErrorCode
+	if errors.As(err, &code) {
+		return &ResponseError{Code: code, Message: err.Error()}
+	}
+	if errors.Is(err, context.Canceled) {
+		return &ResponseError{Code: CodeRequestCancelled, Message: err.Error()}
+	}
+	return &ResponseError{Code: CodeInternalError, Message: err.Error()}
+}
@@ -0,0 +1,107 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestResponseError_Is(t *testing.T) {
+	err := fmt.Errorf("hover: %w", NewError(CodeContentModified, "document changed"))
+
+	if !errors.Is(err, CodeContentModified) {
+		t.Error("errors.Is(err, CodeContentModified) == false")
+	}
+	if !errors.Is(err, NewError(CodeContentModified, "other message")) {
+		t.Error("errors.Is(err, *ResponseError with same code) == false")
+	}
+	if errors.Is(err, CodeRequestCancelled) {
+		t.Error("errors.Is(err, CodeRequestCancelled) == true")
+	}
+
+	var re *ResponseError
+	if !errors.As(err, &re) || re.Message != "document changed" {
+		t.Errorf("errors.As: got %+v", re)
+	}
+}
+
+func TestResponseError_JSON(t *testing.T) {
+	e := Errorf(CodeServerNotInitialized, "%s before initialize", "hover")
+	if err := e.SetData(InitializeError{Retry: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"code":-32002,"message":"hover before initialize","data":{"retry":true}}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var got ResponseError
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, e) {
+		t.Errorf("got %+v, want %+v", got, e)
+	}
+	var ie InitializeError
+	if err := got.DecodeData(&ie); err != nil {
+		t.Fatal(err)
+	}
+	if !ie.Retry {
+		t.Error("got Retry == false")
+	}
+	if want := "lsp: ServerNotInitialized: hover before initialize"; got.Error() != want {
+		t.Errorf("got %q, want %q", got.Error(), want)
+	}
+}
+
+func TestErrorCode_String(t *testing.T) {
+	if got, want := CodeRequestCancelled.String(), "RequestCancelled"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := ErrorCode(-1).String(), "ErrorCode(-1)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestToResponseError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCode
+	}{
+		{err: NewError(CodeInvalidParams, "x"), want: CodeInvalidParams},
+		{err: fmt.Errorf("wrapped: %w", NewError(CodeRequestFailed, "x")), want: CodeRequestFailed},
+		{err: CodeMethodNotFound, want: CodeMethodNotFound},
+		{err: fmt.Errorf("wrapped: %w", context.Canceled), want: CodeRequestCancelled},
+		{err: errors.New("x"), want: CodeInternalError},
+	}
+	for _, test := range tests {
+		if got := ToResponseError(test.err); got.Code != test.want {
+			t.Errorf("%v: got code %s, want %s", test.err, got.Code, test.want)
+		}
+	}
+	if got := ToResponseError(nil); got != nil {
+		t.Errorf("got %v, want nil", got)
+	}
+}
+
+func TestDecodeMessage_errorCodes(t *testing.T) {
+	tests := map[string]ErrorCode{
+		`{`:                          CodeParseError,
+		`[{"jsonrpc":"2.0"`:          CodeParseError,
+		`[]`:                         CodeInvalidRequest,
+		`{"jsonrpc":"2.0"}`:          CodeInvalidRequest,
+		`{"jsonrpc":"2.0","id":1.5}`: CodeInvalidRequest,
+	}
+	for data, want := range tests {
+		_, _, err := DecodeMessages([]byte(data))
+		if !errors.Is(err, want) {
+			t.Errorf("%s: got error %v, want code %s", data, err, want)
+		}
+	}
+}
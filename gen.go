@@ -0,0 +1,237 @@
+//go:build ignore
+// +build ignore
+
+// This program generates server_gen.go, client_gen.go and
+// methods_gen.go from the table of LSP methods below. Run it with
+// "go generate".
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"strings"
+	"text/template"
+)
+
+// method describes an LSP method.
+type method struct {
+	Method string // the method name, such as "textDocument/hover"
+	Name   string // the Go method name, such as "Hover"
+	Params string // the Go params type, or "" if the method has no params
+	Result string // the Go result type, or "" if the method has no result
+	Notify bool   // whether the method is a notification
+	Dir    string // the Direction constant, or "" for ClientToServer
+}
+
+// methods are the LSP methods whose types this package defines.
+var methods = []method{
+	{Method: "initialize", Name: "Initialize", Params: "InitializeParams", Result: "*InitializeResult"},
+	{Method: "initialized", Name: "Initialized", Params: "InitializedParams", Notify: true},
+	{Method: "shutdown", Name: "Shutdown"},
+	{Method: "exit", Name: "Exit", Notify: true},
+
+	{Method: "textDocument/didOpen", Name: "DidOpen", Params: "DidOpenTextDocumentParams", Notify: true},
+	{Method: "textDocument/didChange", Name: "DidChange", Params: "DidChangeTextDocumentParams", Notify: true},
+	{Method: "textDocument/didClose", Name: "DidClose", Params: "DidCloseTextDocumentParams", Notify: true},
+	{Method: "textDocument/didSave", Name: "DidSave", Params: "DidSaveTextDocumentParams", Notify: true},
+	{Method: "workspace/didChangeConfiguration", Name: "DidChangeConfiguration", Params: "DidChangeConfigurationParams", Notify: true},
+	{Method: "workspace/didChangeWatchedFiles", Name: "DidChangeWatchedFiles", Params: "DidChangeWatchedFilesParams", Notify: true},
+
+	{Method: "textDocument/completion", Name: "Completion", Params: "CompletionParams", Result: "*CompletionList"},
+	{Method: "completionItem/resolve", Name: "ResolveCompletionItem", Params: "CompletionItem", Result: "*CompletionItem"},
+	{Method: "textDocument/hover", Name: "Hover", Params: "TextDocumentPositionParams", Result: "*Hover"},
+	{Method: "textDocument/signatureHelp", Name: "SignatureHelp", Params: "TextDocumentPositionParams", Result: "*SignatureHelp"},
+	{Method: "textDocument/definition", Name: "Definition", Params: "TextDocumentPositionParams", Result: "[]Location"},
+	{Method: "textDocument/typeDefinition", Name: "TypeDefinition", Params: "TextDocumentPositionParams", Result: "[]Location"},
+	{Method: "textDocument/implementation", Name: "Implementation", Params: "TextDocumentPositionParams", Result: "[]Location"},
+	{Method: "textDocument/references", Name: "References", Params: "ReferenceParams", Result: "[]Location"},
+	{Method: "textDocument/documentHighlight", Name: "DocumentHighlight", Params: "TextDocumentPositionParams", Result: "[]DocumentHighlight"},
+	{Method: "textDocument/documentSymbol", Name: "DocumentSymbol", Params: "DocumentSymbolParams", Result: "[]SymbolInformation"},
+	{Method: "workspace/symbol", Name: "WorkspaceSymbol", Params: "WorkspaceSymbolParams", Result: "[]SymbolInformation"},
+	{Method: "textDocument/codeAction", Name: "CodeAction", Params: "CodeActionParams", Result: "[]CommandOrCodeAction"},
+	{Method: "codeAction/resolve", Name: "ResolveCodeAction", Params: "CodeAction", Result: "*CodeAction"},
+	{Method: "textDocument/codeLens", Name: "CodeLens", Params: "CodeLensParams", Result: "[]CodeLens"},
+	{Method: "codeLens/resolve", Name: "ResolveCodeLens", Params: "CodeLens", Result: "*CodeLens"},
+	{Method: "textDocument/formatting", Name: "Formatting", Params: "DocumentFormattingParams", Result: "[]TextEdit"},
+	{Method: "textDocument/rangeFormatting", Name: "RangeFormatting", Params: "DocumentRangeFormattingParams", Result: "[]TextEdit"},
+	{Method: "textDocument/onTypeFormatting", Name: "OnTypeFormatting", Params: "DocumentOnTypeFormattingParams", Result: "[]TextEdit"},
+	{Method: "textDocument/rename", Name: "Rename", Params: "RenameParams", Result: "*WorkspaceEdit"},
+	{Method: "textDocument/semanticTokens/full", Name: "SemanticTokensFull", Params: "SemanticTokensParams", Result: "*SemanticTokens"},
+	{Method: "textDocument/semanticTokens/full/delta", Name: "SemanticTokensFullDelta", Params: "SemanticTokensDeltaParams", Result: "*SemanticTokensOrDelta"},
+	{Method: "textDocument/semanticTokens/range", Name: "SemanticTokensRange", Params: "SemanticTokensRangeParams", Result: "*SemanticTokens"},
+	{Method: "textDocument/prepareCallHierarchy", Name: "PrepareCallHierarchy", Params: "CallHierarchyPrepareParams", Result: "[]CallHierarchyItem"},
+	{Method: "callHierarchy/incomingCalls", Name: "IncomingCalls", Params: "CallHierarchyIncomingCallsParams", Result: "[]CallHierarchyIncomingCall"},
+	{Method: "callHierarchy/outgoingCalls", Name: "OutgoingCalls", Params: "CallHierarchyOutgoingCallsParams", Result: "[]CallHierarchyOutgoingCall"},
+	{Method: "textDocument/prepareTypeHierarchy", Name: "PrepareTypeHierarchy", Params: "TypeHierarchyPrepareParams", Result: "[]TypeHierarchyItem"},
+	{Method: "typeHierarchy/supertypes", Name: "Supertypes", Params: "TypeHierarchySupertypesParams", Result: "[]TypeHierarchyItem"},
+	{Method: "typeHierarchy/subtypes", Name: "Subtypes", Params: "TypeHierarchySubtypesParams", Result: "[]TypeHierarchyItem"},
+	{Method: "workspace/executeCommand", Name: "ExecuteCommand", Params: "ExecuteCommandParams", Result: "interface{}"},
+
+	{Method: "window/showMessage", Name: "ShowMessage", Params: "ShowMessageParams", Notify: true, Dir: "ServerToClient"},
+	{Method: "window/showMessageRequest", Name: "ShowMessageRequest", Params: "ShowMessageRequestParams", Result: "*MessageActionItem", Dir: "ServerToClient"},
+	{Method: "window/logMessage", Name: "LogMessage", Params: "LogMessageParams", Notify: true, Dir: "ServerToClient"},
+	{Method: "telemetry/event", Name: "TelemetryEvent", Params: "interface{}", Notify: true, Dir: "ServerToClient"},
+	{Method: "textDocument/publishDiagnostics", Name: "PublishDiagnostics", Params: "PublishDiagnosticsParams", Notify: true, Dir: "ServerToClient"},
+	{Method: "textDocument/semanticHighlighting", Name: "SemanticHighlighting", Params: "SemanticHighlightingParams", Notify: true, Dir: "ServerToClient"},
+	{Method: "workspace/configuration", Name: "WorkspaceConfiguration", Params: "ConfigurationParams", Result: "ConfigurationResult", Dir: "ServerToClient"},
+
+	{Method: "$/cancelRequest", Name: "CancelRequest", Params: "CancelParams", Notify: true, Dir: "BothDirections"},
+}
+
+var funcs = template.FuncMap{
+	// zero returns the zero value of a Go result type.
+	"zero": func(typ string) string {
+		if strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "[]") || typ == "interface{}" {
+			return "nil"
+		}
+		return typ + "{}"
+	},
+}
+
+var serverTemplate = template.Must(template.New("").Funcs(funcs).Parse(`// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// LanguageServer is implemented by language servers, with one method
+// per request or notification sent from the client to the server. Use
+// NewServerHandler to serve it.
+//
+// Implementations should embed UnimplementedLanguageServer so that
+// they only need to implement the methods they support (and so that
+// they keep compiling when methods are added to this interface).
+type LanguageServer interface {
+{{- range .}}
+	// {{.Name}} handles the {{if .Notify}}notification{{else}}request{{end}} "{{.Method}}".
+	{{.Name}}(ctx context.Context{{if .Params}}, params *{{.Params}}{{end}}) {{if .Result}}({{.Result}}, error){{else}}error{{end}}
+{{end -}}
+}
+
+// UnimplementedLanguageServer implements every method of
+// LanguageServer by returning an error with code CodeMethodNotFound.
+type UnimplementedLanguageServer struct{}
+{{range .}}
+func (UnimplementedLanguageServer) {{.Name}}(ctx context.Context{{if .Params}}, params *{{.Params}}{{end}}) {{if .Result}}({{.Result}}, error){{else}}error{{end}} {
+	return {{if .Result}}{{zero .Result}}, {{end}}errMethodNotFound(Method{{.Name}})
+}
+{{end}}
+func dispatchServerRequest(ctx context.Context, s LanguageServer, method string, rawParams *json.RawMessage) (interface{}, error) {
+	switch method {
+{{- range .}}{{if not .Notify}}
+	case Method{{.Name}}:
+{{- if .Params}}
+		var params {{.Params}}
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+{{- end}}
+{{- if .Result}}
+		return s.{{.Name}}(ctx{{if .Params}}, &params{{end}})
+{{- else}}
+		return nil, s.{{.Name}}(ctx{{if .Params}}, &params{{end}})
+{{- end}}
+{{- end}}{{end}}
+	}
+	return nil, errMethodNotFound(method)
+}
+
+func dispatchServerNotification(ctx context.Context, s LanguageServer, method string, rawParams *json.RawMessage) error {
+	switch method {
+{{- range .}}{{if .Notify}}
+	case Method{{.Name}}:
+{{- if .Params}}
+		var params {{.Params}}
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+{{- end}}
+		return s.{{.Name}}(ctx{{if .Params}}, &params{{end}})
+{{- end}}{{end}}
+	}
+	return errMethodNotFound(method)
+}
+`))
+
+var clientTemplate = template.Must(template.New("").Funcs(funcs).Parse(`// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import "context"
+{{range .}}
+// {{.Name}} {{if .Notify}}sends the notification{{else}}calls the request{{end}} "{{.Method}}".
+func (c *Client) {{.Name}}(ctx context.Context{{if .Params}}, params {{.Params}}{{end}}) {{if .Result}}({{.Result}}, error){{else}}error{{end}} {
+{{- if .Notify}}
+	return c.caller.Notify(ctx, Method{{.Name}}, {{if .Params}}&params{{else}}nil{{end}})
+{{- else if .Result}}
+	var result {{.Result}}
+	if err := c.caller.Call(ctx, Method{{.Name}}, {{if .Params}}&params{{else}}nil{{end}}, &result); err != nil {
+		return {{zero .Result}}, err
+	}
+	return result, nil
+{{- else}}
+	return c.caller.Call(ctx, Method{{.Name}}, {{if .Params}}&params{{else}}nil{{end}}, nil)
+{{- end}}
+}
+{{end}}`))
+
+var methodsTemplate = template.Must(template.New("").Funcs(funcs).Parse(`// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import "reflect"
+
+// The names of the LSP methods whose types this package defines.
+const (
+{{- range .}}
+	Method{{.Name}} = "{{.Method}}"
+{{- end}}
+)
+
+func init() {
+{{- range .}}
+	RegisterMethod(MethodInfo{
+		Method:       Method{{.Name}},
+		Direction:    {{or .Dir "ClientToServer"}},
+		Notification: {{.Notify}},
+{{- if .Params}}
+		Params:       reflect.TypeOf((*{{.Params}})(nil)).Elem(),
+{{- end}}
+{{- if .Result}}
+		Result:       reflect.TypeOf((*{{.Result}})(nil)).Elem(),
+{{- end}}
+	})
+{{- end}}
+}
+`))
+
+func main() {
+	var clientToServer []method
+	for _, m := range methods {
+		if m.Dir == "" {
+			clientToServer = append(clientToServer, m)
+		}
+	}
+	generate("server_gen.go", serverTemplate, clientToServer)
+	generate("client_gen.go", clientTemplate, clientToServer)
+	generate("methods_gen.go", methodsTemplate, methods)
+}
+
+func generate(filename string, tmpl *template.Template, data interface{}) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("%s: %s\n%s", filename, err, buf.Bytes())
+	}
+	if err := ioutil.WriteFile(filename, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
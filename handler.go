@@ -0,0 +1,59 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Handler handles incoming JSON-RPC requests and notifications.
+type Handler interface {
+	// HandleRequest handles a request and returns its result, which is
+	// JSON-encoded in the response. A non-nil error is sent to the
+	// peer as converted by ToResponseError.
+	HandleRequest(ctx context.Context, req *Request) (result interface{}, err error)
+
+	// HandleNotification handles a notification. Since notifications
+	// have no response, the error is only reported locally.
+	HandleNotification(ctx context.Context, n *Notification) error
+}
+
+// NewServerHandler returns a Handler that decodes the params of the
+// requests and notifications defined by LanguageServer and calls the
+// corresponding method of s.
+//
+// Requests for methods that s does not implement (that is, methods
+// for which s returns CodeMethodNotFound, as those of an embedded
+// UnimplementedLanguageServer do) and for methods unknown to
+// LanguageServer fail with CodeMethodNotFound. Requests whose params
+// cannot be decoded fail with CodeInvalidParams.
+func NewServerHandler(s LanguageServer) Handler {
+	return serverHandler{s: s}
+}
+
+type serverHandler struct {
+	s LanguageServer
+}
+
+func (h serverHandler) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	return dispatchServerRequest(ctx, h.s, req.Method, req.Params)
+}
+
+func (h serverHandler) HandleNotification(ctx context.Context, n *Notification) error {
+	return dispatchServerNotification(ctx, h.s, n.Method, n.Params)
+}
+
+// unmarshalParams decodes params into v. Absent and null params leave
+// v unchanged.
+func unmarshalParams(params *json.RawMessage, v interface{}) error {
+	if params == nil || string(*params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(*params, v); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %s", err)
+	}
+	return nil
+}
+
+func errMethodNotFound(method string) error {
+	return Errorf(CodeMethodNotFound, "method not found: %s", method)
+}
@@ -0,0 +1,91 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testServer struct {
+	UnimplementedLanguageServer
+	opened []DocumentURI
+}
+
+func (s *testServer) Hover(ctx context.Context, params *TextDocumentPositionParams) (*Hover, error) {
+	if params.TextDocument.URI == "file:///none" {
+		return nil, nil
+	}
+	return &Hover{Contents: []MarkedString{RawMarkedString(params.Position.String())}}, nil
+}
+
+func (s *testServer) DidOpen(ctx context.Context, params *DidOpenTextDocumentParams) error {
+	s.opened = append(s.opened, params.TextDocument.URI)
+	return nil
+}
+
+func TestServerHandler(t *testing.T) {
+	s := &testServer{}
+	h := NewServerHandler(s)
+	ctx := context.Background()
+
+	result, err := h.HandleRequest(ctx, &Request{
+		Method: "textDocument/hover",
+		Params: rawMessage(`{"textDocument":{"uri":"file:///a"},"position":{"line":1,"character":2}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Hover{Contents: []MarkedString{RawMarkedString("1:2")}}); !reflect.DeepEqual(result, want) {
+		t.Errorf("got %+v, want %+v", result, want)
+	}
+
+	result, err = h.HandleRequest(ctx, &Request{
+		Method: "textDocument/hover",
+		Params: rawMessage(`{"textDocument":{"uri":"file:///none"}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.(*Hover) != nil {
+		t.Errorf("got %+v, want nil", result)
+	}
+
+	if err := h.HandleNotification(ctx, &Notification{
+		Method: "textDocument/didOpen",
+		Params: rawMessage(`{"textDocument":{"uri":"file:///a","languageId":"go","version":1,"text":""}}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []DocumentURI{"file:///a"}; !reflect.DeepEqual(s.opened, want) {
+		t.Errorf("got opened %v, want %v", s.opened, want)
+	}
+}
+
+func TestServerHandler_errors(t *testing.T) {
+	h := NewServerHandler(&testServer{})
+	ctx := context.Background()
+
+	tests := []struct {
+		req  *Request
+		want ErrorCode
+	}{
+		{req: &Request{Method: "textDocument/definition"}, want: CodeMethodNotFound},
+		{req: &Request{Method: "shutdown"}, want: CodeMethodNotFound},
+		{req: &Request{Method: "unknown/method"}, want: CodeMethodNotFound},
+		{req: &Request{Method: "textDocument/hover", Params: rawMessage(`{"position":1}`)}, want: CodeInvalidParams},
+		{req: &Request{Method: "textDocument/hover", Params: rawMessage(`[]`)}, want: CodeInvalidParams},
+	}
+	for _, test := range tests {
+		if _, err := h.HandleRequest(ctx, test.req); !errors.Is(err, test.want) {
+			t.Errorf("%s: got error %v, want code %s", test.req.Method, err, test.want)
+		}
+	}
+
+	if err := h.HandleNotification(ctx, &Notification{Method: "textDocument/didClose"}); !errors.Is(err, CodeMethodNotFound) {
+		t.Errorf("got error %v, want code %s", err, CodeMethodNotFound)
+	}
+	if err := h.HandleNotification(ctx, &Notification{Method: "textDocument/didOpen", Params: rawMessage(`1`)}); !errors.Is(err, CodeInvalidParams) {
+		t.Errorf("got error %v, want code %s", err, CodeInvalidParams)
+	}
+}
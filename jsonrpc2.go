@@ -1,12 +1,20 @@
 package lsp
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 )
 
 // ID represents a JSON-RPC 2.0 request ID, which may be either a
-// string or number (or null, which is unsupported).
+// string, an integer or null.
+//
+// IDs are comparable and integers are unmarshaled into a canonical
+// form, so they can be used as map keys (for example, to track pending
+// requests). Other numbers are kept as received, so 1.0 is a
+// different ID from 1.
 type ID struct {
 	// At most one of Num or Str may be nonzero. If both are zero
 	// valued, then IsNum specifies which field's value is to be used
@@ -18,11 +26,53 @@ type ID struct {
 	// used as the ID, when both are zero valued. It must always be
 	// set to true if the request ID is a string.
 	IsString bool
+
+	// IsNeg is true if the ID is a negative number, whose value is
+	// -Num. It must be false if Num is zero.
+	IsNeg bool
+
+	// IsNull is true if the ID is null. Null IDs only occur in
+	// responses to messages whose ID could not be determined, such as
+	// parse errors. If IsNull is true, all other fields must be zero
+	// valued.
+	IsNull bool
+
+	// Raw is the JSON text of a number that is not an integer in
+	// canonical form, such as -0, 1.0 or 1e3, which JSON-RPC allows
+	// (though discourages). The ID is encoded as Raw, so that it is
+	// sent back as it was received. If Raw is set, all other fields
+	// must be zero valued.
+	Raw string
+}
+
+// IntID returns the ID for the number n.
+func IntID(n int64) ID {
+	if n < 0 {
+		return ID{Num: uint64(-(n + 1)) + 1, IsNeg: true}
+	}
+	return ID{Num: uint64(n)}
+}
+
+// StringID returns the ID for the string s.
+func StringID(s string) ID {
+	return ID{Str: s, IsString: true}
+}
+
+// NullID returns the null ID.
+func NullID() ID {
+	return ID{IsNull: true}
 }
 
 func (id ID) String() string {
-	if id.IsString {
+	switch {
+	case id.IsNull:
+		return "null"
+	case id.Raw != "":
+		return id.Raw
+	case id.IsString:
 		return strconv.Quote(id.Str)
+	case id.IsNeg:
+		return "-" + strconv.FormatUint(id.Num, 10)
 	}
 	return strconv.FormatUint(id.Num, 10)
 }
@@ -32,21 +82,318 @@ func (id ID) MarshalJSON() ([]byte, error) {
 	if id.IsString {
 		return json.Marshal(id.Str)
 	}
-	return json.Marshal(id.Num)
+	return []byte(id.String()), nil
 }
 
-// UnmarshalJSON implements json.Unmarshaler.
+// UnmarshalJSON implements json.Unmarshaler. It accepts strings, null
+// and numbers. Integers in the range of int64 or uint64 are stored in
+// Num, and other numbers (including those with fraction or exponent
+// parts, and -0) in Raw.
 func (id *ID) UnmarshalJSON(data []byte) error {
-	// Support both uint64 and string IDs.
-	var v uint64
-	if err := json.Unmarshal(data, &v); err == nil {
-		*id = ID{Num: v}
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "null":
+		*id = ID{IsNull: true}
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = ID{Str: s, IsString: true}
 		return nil
 	}
-	var v2 string
-	if err := json.Unmarshal(data, &v2); err != nil {
-		return err
+
+	if len(data) == 0 || data[0] != '-' && (data[0] < '0' || data[0] > '9') || !json.Valid(data) {
+		return fmt.Errorf("lsp: invalid ID %s", data)
+	}
+	digits := data
+	neg := digits[0] == '-'
+	if neg {
+		digits = digits[1:]
 	}
-	*id = ID{Str: v2, IsString: true}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			*id = ID{Raw: string(data)} // a fraction or exponent
+			return nil
+		}
+	}
+	n, err := strconv.ParseUint(string(digits), 10, 64)
+	if err != nil {
+		return fmt.Errorf("lsp: invalid ID %s: %s", data, err)
+	}
+	if neg && n == 0 {
+		*id = ID{Raw: string(data)}
+		return nil
+	}
+	*id = ID{Num: n, IsNeg: neg}
 	return nil
 }
+
+// Message is a JSON-RPC 2.0 message: a *Request, a *Notification or a
+// *Response.
+type Message interface {
+	json.Marshaler
+	isMessage()
+}
+
+// Request is a JSON-RPC 2.0 request, which expects a Response with
+// the same ID.
+type Request struct {
+	ID     ID               `json:"id"`
+	Method string           `json:"method"`
+	Params *json.RawMessage `json:"params,omitempty"`
+}
+
+// Notification is a JSON-RPC 2.0 notification, a request that has no
+// ID and does not expect a response.
+type Notification struct {
+	Method string           `json:"method"`
+	Params *json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response. At most one of Result and Error
+// may be set; if neither is, the result is null.
+type Response struct {
+	ID     ID               `json:"id"`
+	Result *json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError   `json:"error,omitempty"`
+}
+
+func (*Request) isMessage()      {}
+func (*Notification) isMessage() {}
+func (*Response) isMessage()     {}
+
+// SetParams sets r.Params to the JSON encoding of v.
+func (r *Request) SetParams(v interface{}) error {
+	raw, err := marshalRaw(v)
+	r.Params = raw
+	return err
+}
+
+// SetParams sets n.Params to the JSON encoding of v.
+func (n *Notification) SetParams(v interface{}) error {
+	raw, err := marshalRaw(v)
+	n.Params = raw
+	return err
+}
+
+// SetResult sets r.Result to the JSON encoding of v.
+func (r *Response) SetResult(v interface{}) error {
+	raw, err := marshalRaw(v)
+	r.Result = raw
+	return err
+}
+
+func marshalRaw(v interface{}) (*json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(data)
+	return &raw, nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Request) MarshalJSON() ([]byte, error) {
+	type request Request
+	return json.Marshal(struct {
+		JSONRPC string `json:"jsonrpc"`
+		*request
+	}{JSONRPC: "2.0", request: (*request)(r)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (n *Notification) MarshalJSON() ([]byte, error) {
+	type notification Notification
+	return json.Marshal(struct {
+		JSONRPC string `json:"jsonrpc"`
+		*notification
+	}{JSONRPC: "2.0", notification: (*notification)(n)})
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	if r.Error != nil && r.Result != nil {
+		return nil, errors.New("lsp: response must not have both result and error")
+	}
+	v := struct {
+		JSONRPC string           `json:"jsonrpc"`
+		ID      ID               `json:"id"`
+		Result  *json.RawMessage `json:"result,omitempty"`
+		Error   *ResponseError   `json:"error,omitempty"`
+	}{JSONRPC: "2.0", ID: r.ID, Result: r.Result, Error: r.Error}
+	if v.Error == nil && v.Result == nil {
+		null := json.RawMessage("null")
+		v.Result = &null
+	}
+	return json.Marshal(v)
+}
+
+// DecodeMessage decodes a single JSON-RPC 2.0 message, classifying it
+// by the presence of its "id" and "method" members: a message with
+// both is a *Request, one with only "method" is a *Notification, and
+// one with only "id" is a *Response.
+//
+// If data is not a valid message, the returned error is a
+// *ResponseError with code CodeParseError or CodeInvalidRequest,
+// suitable for sending back to the peer.
+func DecodeMessage(data []byte) (Message, error) {
+	msg, err := decodeMessage(data)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	return msg, nil
+}
+
+// decodeError converts an error encountered while decoding a message
+// into the *ResponseError that a peer should receive.
+func decodeError(err error) error {
+	var re *ResponseError
+	if errors.As(err, &re) {
+		return err
+	}
+	code := CodeInvalidRequest
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		code = CodeParseError
+	}
+	return &ResponseError{Code: code, Message: err.Error()}
+}
+
+func decodeMessage(data []byte) (Message, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("lsp: message is not an object")
+	}
+	var version string
+	if err := json.Unmarshal(fields["jsonrpc"], &version); err != nil || version != "2.0" {
+		return nil, errors.New(`lsp: message does not have "jsonrpc":"2.0"`)
+	}
+
+	rawID, hasID := fields["id"]
+	rawMethod, hasMethod := fields["method"]
+	var method string
+	if hasMethod {
+		if err := json.Unmarshal(rawMethod, &method); err != nil {
+			return nil, fmt.Errorf("lsp: invalid method: %s", err)
+		}
+	}
+
+	switch {
+	case hasMethod && hasID:
+		req := &Request{Method: method, Params: rawField(fields, "params")}
+		if err := json.Unmarshal(rawID, &req.ID); err != nil {
+			return nil, fmt.Errorf("lsp: invalid id: %s", err)
+		}
+		return req, nil
+
+	case hasMethod:
+		return &Notification{Method: method, Params: rawField(fields, "params")}, nil
+
+	case hasID:
+		resp := &Response{Result: rawField(fields, "result")}
+		if err := json.Unmarshal(rawID, &resp.ID); err != nil {
+			return nil, fmt.Errorf("lsp: invalid id: %s", err)
+		}
+		if rawErr := rawField(fields, "error"); rawErr != nil && string(*rawErr) != "null" {
+			resp.Error = &ResponseError{}
+			if err := json.Unmarshal(*rawErr, resp.Error); err != nil {
+				return nil, fmt.Errorf("lsp: invalid error: %s", err)
+			}
+		}
+		if resp.Result == nil && resp.Error == nil {
+			return nil, errors.New(`lsp: response has neither "result" nor "error"`)
+		}
+		if resp.Result != nil && resp.Error != nil {
+			return nil, errors.New(`lsp: response has both "result" and "error"`)
+		}
+		return resp, nil
+	}
+	return nil, errors.New(`lsp: message has neither "id" nor "method"`)
+}
+
+// rawField returns the named member of an object, or nil if it is
+// absent.
+func rawField(fields map[string]json.RawMessage, name string) *json.RawMessage {
+	v, ok := fields[name]
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+// DecodeMessages decodes either a single JSON-RPC 2.0 message or a
+// batch (a JSON array of messages). The batch result reports whether
+// data was a batch, in which case any responses to its requests should
+// also be sent as a batch.
+//
+// The invalid elements of a batch do not prevent the others from being
+// decoded: msgs holds the valid elements, in order, and err is a
+// BatchErrors with an error for each invalid element, so that a
+// response can be sent for each. Otherwise, if data is not a message
+// or a non-empty batch, err is a *ResponseError as for DecodeMessage.
+func DecodeMessages(data []byte) (msgs []Message, batch bool, err error) {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 || data[0] != '[' {
+		msg, err := DecodeMessage(data)
+		if err != nil {
+			return nil, false, err
+		}
+		return []Message{msg}, false, nil
+	}
+
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, true, decodeError(err)
+	}
+	if len(raws) == 0 {
+		return nil, true, &ResponseError{Code: CodeInvalidRequest, Message: "lsp: empty batch"}
+	}
+	msgs = make([]Message, 0, len(raws))
+	var errs BatchErrors
+	for i, raw := range raws {
+		msg, err := DecodeMessage(raw)
+		if err != nil {
+			errs = append(errs, &BatchError{Index: i, Err: err})
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+	if errs != nil {
+		return msgs, true, errs
+	}
+	return msgs, true, nil
+}
+
+// BatchError is an error decoding an element of a batch.
+type BatchError struct {
+	// Index is the index of the element in the batch.
+	Index int
+
+	// Err is the *ResponseError to send in response to the element.
+	Err error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("lsp: batch element %d: %s", e.Index, e.Err)
+}
+
+// Unwrap returns e.Err.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// BatchErrors is the error returned by DecodeMessages for a batch with
+// invalid elements, in order.
+type BatchErrors []*BatchError
+
+func (e BatchErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", e[0], len(e)-1)
+}
@@ -0,0 +1,234 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func rawMessage(s string) *json.RawMessage {
+	v := json.RawMessage(s)
+	return &v
+}
+
+func TestMessage_MarshalDecode(t *testing.T) {
+	tests := []struct {
+		data string
+		want Message
+	}{{
+		data: `{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"a":1}}`,
+		want: &Request{ID: ID{Num: 1}, Method: "textDocument/hover", Params: rawMessage(`{"a":1}`)},
+	}, {
+		data: `{"jsonrpc":"2.0","id":"x","method":"shutdown"}`,
+		want: &Request{ID: ID{Str: "x", IsString: true}, Method: "shutdown"},
+	}, {
+		data: `{"jsonrpc":"2.0","method":"exit"}`,
+		want: &Notification{Method: "exit"},
+	}, {
+		data: `{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		want: &Notification{Method: "initialized", Params: rawMessage(`{}`)},
+	}, {
+		data: `{"jsonrpc":"2.0","id":2,"result":null}`,
+		want: &Response{ID: ID{Num: 2}, Result: rawMessage(`null`)},
+	}, {
+		data: `{"jsonrpc":"2.0","id":2,"result":[1,2]}`,
+		want: &Response{ID: ID{Num: 2}, Result: rawMessage(`[1,2]`)},
+	}, {
+		data: `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"m","data":{"retry":true}}}`,
+		want: &Response{ID: ID{Num: 3}, Error: &ResponseError{Code: -32601, Message: "m", Data: rawMessage(`{"retry":true}`)}},
+	}}
+	for _, test := range tests {
+		got, err := DecodeMessage([]byte(test.data))
+		if err != nil {
+			t.Errorf("%s: %s", test.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.data, got, test.want)
+			continue
+		}
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("%s: %s", test.data, err)
+			continue
+		}
+		if string(data) != test.data {
+			t.Errorf("got JSON %s, want %s", data, test.data)
+		}
+	}
+}
+
+func TestDecodeMessage_invalid(t *testing.T) {
+	for _, data := range []string{
+		`null`,
+		`[]`,
+		`{"id":1,"method":"m"}`,
+		`{"jsonrpc":"1.0","id":1,"method":"m"}`,
+		`{"jsonrpc":"2.0"}`,
+		`{"jsonrpc":"2.0","id":1}`,
+		`{"jsonrpc":"2.0","id":1,"result":1,"error":{"code":1,"message":""}}`,
+		`{"jsonrpc":"2.0","id":{},"method":"m"}`,
+		`{"jsonrpc":"2.0","method":1}`,
+	} {
+		if msg, err := DecodeMessage([]byte(data)); err == nil {
+			t.Errorf("%s: got %+v, want error", data, msg)
+		}
+	}
+}
+
+func TestResponse_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(&Response{ID: ID{Num: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"jsonrpc":"2.0","id":1,"result":null}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	if _, err := json.Marshal(&Response{Result: rawMessage("1"), Error: &ResponseError{}}); err == nil {
+		t.Error("got nil error for response with both result and error")
+	}
+}
+
+func TestDecodeMessages(t *testing.T) {
+	msgs, batch, err := DecodeMessages([]byte(` [{"jsonrpc":"2.0","id":1,"method":"a"},{"jsonrpc":"2.0","method":"b"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !batch {
+		t.Error("got batch == false")
+	}
+	want := []Message{&Request{ID: ID{Num: 1}, Method: "a"}, &Notification{Method: "b"}}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("got %+v, want %+v", msgs, want)
+	}
+	data, err := json.Marshal(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"jsonrpc":"2.0","id":1,"method":"a"},{"jsonrpc":"2.0","method":"b"}]`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	msgs, batch, err = DecodeMessages([]byte(`{"jsonrpc":"2.0","method":"b"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch || len(msgs) != 1 {
+		t.Errorf("got %d messages (batch: %v), want 1 non-batch message", len(msgs), batch)
+	}
+
+	for _, data := range []string{`[]`, `[{"jsonrpc":"2.0"`, `{"jsonrpc":"2.0"}`} {
+		if _, _, err := DecodeMessages([]byte(data)); err == nil {
+			t.Errorf("%s: got nil error", data)
+		}
+	}
+
+	// The valid elements of a batch are decoded despite the invalid
+	// ones.
+	msgs, batch, err = DecodeMessages([]byte(`[1,{"jsonrpc":"2.0","id":1,"method":"a"},{"jsonrpc":"2.0"},{"jsonrpc":"2.0","method":"b"}]`))
+	if !batch {
+		t.Error("got batch == false")
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("got %+v, want %+v", msgs, want)
+	}
+	errs, ok := err.(BatchErrors)
+	if !ok {
+		t.Fatalf("got error %v, want BatchErrors", err)
+	}
+	var indexes []int
+	for _, e := range errs {
+		indexes = append(indexes, e.Index)
+		if !errors.Is(e, CodeInvalidRequest) {
+			t.Errorf("got error %v, want code %s", e, CodeInvalidRequest)
+		}
+	}
+	if want := []int{0, 2}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("got errors at %v, want %v", indexes, want)
+	}
+}
+
+func TestID_MarshalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want ID
+	}{
+		{data: `0`, want: ID{}},
+		{data: `123`, want: IntID(123)},
+		{data: `18446744073709551615`, want: ID{Num: 1<<64 - 1}},
+		{data: `-1`, want: IntID(-1)},
+		{data: `-9223372036854775808`, want: IntID(-1 << 63)},
+		{data: `-18446744073709551615`, want: ID{Num: 1<<64 - 1, IsNeg: true}},
+		{data: `""`, want: StringID("")},
+		{data: `"123"`, want: StringID("123")},
+		{data: `"a\"b"`, want: StringID(`a"b`)},
+		{data: `null`, want: NullID()},
+		{data: `-0`, want: ID{Raw: "-0"}},
+		{data: `1.0`, want: ID{Raw: "1.0"}},
+		{data: `1e3`, want: ID{Raw: "1e3"}},
+		{data: `-2.5E-1`, want: ID{Raw: "-2.5E-1"}},
+	}
+	for _, test := range tests {
+		var got ID
+		if err := json.Unmarshal([]byte(test.data), &got); err != nil {
+			t.Errorf("%s: %s", test.data, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.data, got, test.want)
+			continue
+		}
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Errorf("%s: %s", test.data, err)
+			continue
+		}
+		if string(data) != test.data {
+			t.Errorf("got JSON %s, want %s", data, test.data)
+		}
+	}
+
+	var id ID
+	for _, data := range []string{`01`, `-`, `1.`, `.5`, `+1`, `18446744073709551616`, `true`, `{}`, `[]`} {
+		if err := json.Unmarshal([]byte(data), &id); err == nil {
+			t.Errorf("%s: got %+v, want error", data, id)
+		}
+	}
+}
+
+func TestID_mapKey(t *testing.T) {
+	pending := map[ID]string{}
+	for _, data := range []string{`1`, `"1"`, `-1`, `null`, `1.0`} {
+		var id ID
+		if err := json.Unmarshal([]byte(data), &id); err != nil {
+			t.Fatal(err)
+		}
+		pending[id] = data
+	}
+	for id, want := range map[ID]string{IntID(1): `1`, StringID("1"): `"1"`, IntID(-1): `-1`, NullID(): `null`, {Raw: "1.0"}: `1.0`} {
+		if got := pending[id]; got != want {
+			t.Errorf("pending[%s] = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestResponse_nullID(t *testing.T) {
+	data := `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`
+	msg, err := DecodeMessage([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, ok := msg.(*Response)
+	if !ok || !resp.ID.IsNull {
+		t.Fatalf("got %+v, want response with null ID", msg)
+	}
+	got, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != data {
+		t.Errorf("got %s, want %s", got, data)
+	}
+}
@@ -0,0 +1,120 @@
+package lsp
+
+import (
+	"context"
+	"sync"
+)
+
+type lifecycleState int
+
+const (
+	lsUninitialized lifecycleState = iota
+	lsInitializing
+	lsInitialized
+	lsShutdown
+	lsExited
+)
+
+// Lifecycle is a Handler that enforces the rules of the LSP server
+// lifecycle before passing requests and notifications to another
+// Handler:
+//
+//   - Before a successful initialize request, other requests fail with
+//     CodeServerNotInitialized, and notifications other than exit are
+//     dropped. If the initialize request fails, another may be sent.
+//   - A second initialize request fails with CodeInvalidRequest.
+//   - After a shutdown request, other requests fail with
+//     CodeInvalidRequest, and notifications other than exit are
+//     dropped.
+//   - The exit notification ends the lifecycle. The exit code that the
+//     server process should use is 0 if a shutdown request was received
+//     before it, and 1 otherwise.
+type Lifecycle struct {
+	h Handler
+
+	mu       sync.Mutex
+	state    lifecycleState
+	exitCode int
+	exited   chan struct{}
+}
+
+// NewLifecycle returns a Lifecycle that passes the requests and
+// notifications that the lifecycle allows to h.
+func NewLifecycle(h Handler) *Lifecycle {
+	return &Lifecycle{h: h, exited: make(chan struct{})}
+}
+
+// HandleRequest implements Handler.
+func (l *Lifecycle) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	l.mu.Lock()
+	state := l.state
+	switch {
+	case req.Method == MethodInitialize && state == lsUninitialized:
+		l.state = lsInitializing
+	case req.Method == MethodInitialize:
+		l.mu.Unlock()
+		return nil, NewError(CodeInvalidRequest, "initialize request already received")
+	case state == lsUninitialized || state == lsInitializing:
+		l.mu.Unlock()
+		return nil, Errorf(CodeServerNotInitialized, "%s request received before initialize", req.Method)
+	case state == lsShutdown || state == lsExited:
+		l.mu.Unlock()
+		return nil, Errorf(CodeInvalidRequest, "%s request received after shutdown", req.Method)
+	case req.Method == MethodShutdown:
+		l.state = lsShutdown
+	}
+	l.mu.Unlock()
+
+	result, err := l.h.HandleRequest(ctx, req)
+	if req.Method == MethodInitialize {
+		l.mu.Lock()
+		if err != nil {
+			l.state = lsUninitialized
+		} else {
+			l.state = lsInitialized
+		}
+		l.mu.Unlock()
+	}
+	return result, err
+}
+
+// HandleNotification implements Handler.
+func (l *Lifecycle) HandleNotification(ctx context.Context, n *Notification) error {
+	l.mu.Lock()
+	state := l.state
+	if n.Method != MethodExit {
+		l.mu.Unlock()
+		if state != lsInitialized {
+			return nil
+		}
+		return l.h.HandleNotification(ctx, n)
+	}
+
+	if state == lsExited {
+		l.mu.Unlock()
+		return nil
+	}
+	l.state = lsExited
+	if state != lsShutdown {
+		l.exitCode = 1
+	}
+	l.mu.Unlock()
+
+	defer close(l.exited)
+	return l.h.HandleNotification(ctx, n)
+}
+
+// Exited returns a channel that is closed when the exit notification
+// has been handled.
+func (l *Lifecycle) Exited() <-chan struct{} {
+	return l.exited
+}
+
+// ExitCode returns the exit code that the server process should use
+// after the exit notification: 0 if a shutdown request preceded it,
+// and 1 otherwise. It must only be called after Exited is closed.
+func (l *Lifecycle) ExitCode() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.exitCode
+}
@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recordingHandler records the methods it handles. Requests for
+// methods in fail fail.
+type recordingHandler struct {
+	handled []string
+	fail    map[string]bool
+	block   chan struct{} // if non-nil, initialize waits for it to be closed
+}
+
+func (h *recordingHandler) HandleRequest(ctx context.Context, req *Request) (interface{}, error) {
+	if req.Method == MethodInitialize && h.block != nil {
+		<-h.block
+	}
+	h.handled = append(h.handled, req.Method)
+	if h.fail[req.Method] {
+		return nil, errors.New("failed")
+	}
+	return nil, nil
+}
+
+func (h *recordingHandler) HandleNotification(ctx context.Context, n *Notification) error {
+	h.handled = append(h.handled, n.Method)
+	return nil
+}
+
+type lifecycleStep struct {
+	method string
+	notify bool
+	want   error // the expected error, either an ErrorCode or nil
+}
+
+func TestLifecycle(t *testing.T) {
+	var (
+		initialize  = lifecycleStep{method: MethodInitialize}
+		initialized = lifecycleStep{method: MethodInitialized, notify: true}
+		hover       = lifecycleStep{method: MethodHover}
+		didOpen     = lifecycleStep{method: MethodDidOpen, notify: true}
+		shutdown    = lifecycleStep{method: MethodShutdown}
+		exit        = lifecycleStep{method: MethodExit, notify: true}
+	)
+	fails := func(step lifecycleStep, code ErrorCode) lifecycleStep {
+		step.want = code
+		return step
+	}
+
+	tests := map[string]struct {
+		steps        []lifecycleStep
+		fail         map[string]bool
+		wantHandled  []string
+		wantExitCode int
+	}{
+		"normal": {
+			steps:        []lifecycleStep{initialize, initialized, didOpen, hover, shutdown, exit},
+			wantHandled:  []string{MethodInitialize, MethodInitialized, MethodDidOpen, MethodHover, MethodShutdown, MethodExit},
+			wantExitCode: 0,
+		},
+		"before initialize": {
+			steps: []lifecycleStep{
+				fails(hover, CodeServerNotInitialized),
+				fails(shutdown, CodeServerNotInitialized),
+				didOpen,
+				initialized,
+				initialize,
+				hover,
+			},
+			wantHandled: []string{MethodInitialize, MethodHover},
+		},
+		"second initialize": {
+			steps:       []lifecycleStep{initialize, fails(initialize, CodeInvalidRequest), hover},
+			wantHandled: []string{MethodInitialize, MethodHover},
+		},
+		"failed initialize": {
+			steps: []lifecycleStep{
+				fails(initialize, CodeInternalError),
+				fails(hover, CodeServerNotInitialized),
+				fails(initialize, CodeInternalError),
+			},
+			fail:        map[string]bool{MethodInitialize: true},
+			wantHandled: []string{MethodInitialize, MethodInitialize},
+		},
+		"after shutdown": {
+			steps: []lifecycleStep{
+				initialize,
+				shutdown,
+				fails(hover, CodeInvalidRequest),
+				fails(shutdown, CodeInvalidRequest),
+				fails(initialize, CodeInvalidRequest),
+				didOpen,
+				exit,
+			},
+			wantHandled:  []string{MethodInitialize, MethodShutdown, MethodExit},
+			wantExitCode: 0,
+		},
+		"exit without shutdown": {
+			steps:        []lifecycleStep{initialize, hover, exit},
+			wantHandled:  []string{MethodInitialize, MethodHover, MethodExit},
+			wantExitCode: 1,
+		},
+		"exit before initialize": {
+			steps:        []lifecycleStep{exit},
+			wantHandled:  []string{MethodExit},
+			wantExitCode: 1,
+		},
+		"after exit": {
+			steps: []lifecycleStep{
+				initialize,
+				shutdown,
+				exit,
+				fails(hover, CodeInvalidRequest),
+				fails(initialize, CodeInvalidRequest),
+				didOpen,
+				exit,
+			},
+			wantHandled:  []string{MethodInitialize, MethodShutdown, MethodExit},
+			wantExitCode: 0,
+		},
+	}
+	for label, test := range tests {
+		h := &recordingHandler{fail: test.fail}
+		l := NewLifecycle(h)
+		for i, step := range test.steps {
+			var err error
+			if step.notify {
+				err = l.HandleNotification(context.Background(), &Notification{Method: step.method})
+			} else {
+				_, err = l.HandleRequest(context.Background(), &Request{Method: step.method})
+			}
+			if code := ToResponseError(err); (code == nil) != (step.want == nil) || code != nil && !errors.Is(code, step.want) {
+				t.Errorf("%s: step %d (%s): got error %v, want %v", label, i, step.method, err, step.want)
+			}
+		}
+		if !reflect.DeepEqual(h.handled, test.wantHandled) {
+			t.Errorf("%s: got handled %q, want %q", label, h.handled, test.wantHandled)
+		}
+
+		exited := false
+		select {
+		case <-l.Exited():
+			exited = true
+		default:
+		}
+		wantExited := len(test.wantHandled) > 0 && test.wantHandled[len(test.wantHandled)-1] == MethodExit
+		if exited != wantExited {
+			t.Errorf("%s: got exited %v, want %v", label, exited, wantExited)
+		}
+		if exited && l.ExitCode() != test.wantExitCode {
+			t.Errorf("%s: got exit code %d, want %d", label, l.ExitCode(), test.wantExitCode)
+		}
+	}
+}
+
+func TestLifecycle_initializing(t *testing.T) {
+	h := &recordingHandler{block: make(chan struct{})}
+	l := NewLifecycle(h)
+
+	done := make(chan error)
+	go func() {
+		_, err := l.HandleRequest(context.Background(), &Request{Method: MethodInitialize})
+		done <- err
+	}()
+
+	// Wait for the initialize request to be in progress.
+	for {
+		l.mu.Lock()
+		state := l.state
+		l.mu.Unlock()
+		if state == lsInitializing {
+			break
+		}
+	}
+	if _, err := l.HandleRequest(context.Background(), &Request{Method: MethodHover}); !errors.Is(err, CodeServerNotInitialized) {
+		t.Errorf("got error %v, want code %s", err, CodeServerNotInitialized)
+	}
+	if _, err := l.HandleRequest(context.Background(), &Request{Method: MethodInitialize}); !errors.Is(err, CodeInvalidRequest) {
+		t.Errorf("got error %v, want code %s", err, CodeInvalidRequest)
+	}
+
+	close(h.block)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{MethodInitialize}; !reflect.DeepEqual(h.handled, want) {
+		t.Errorf("got handled %q, want %q", h.handled, want)
+	}
+}
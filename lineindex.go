@@ -0,0 +1,154 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LineIndex is the text of a document together with the offsets at
+// which its lines start, for fast conversion between positions and
+// byte offsets.
+//
+// Converting a position to an offset takes constant time to find the
+// line and time linear in the length of the line to find the
+// character; converting an offset to a position takes logarithmic time
+// in the number of lines, plus time linear in the length of the line.
+//
+// Lines may be terminated by "\n", "\r\n" or "\r".
+type LineIndex struct {
+	text  string
+	enc   PositionEncodingKind
+	lines []int // the offset of the start of each line; lines[0] == 0
+}
+
+// NewLineIndex returns a LineIndex for text, whose positions have
+// their Character expressed in the given encoding (or UTF-16, if enc
+// is empty).
+func NewLineIndex(text string, enc PositionEncodingKind) *LineIndex {
+	x := &LineIndex{text: text, enc: enc}
+	x.lines = appendLineStarts([]int{0}, text, 1, len(text))
+	return x
+}
+
+// appendLineStarts appends to lines the offsets in [from, to] at which
+// lines of text start.
+func appendLineStarts(lines []int, text string, from, to int) []int {
+	for p := from; p <= to; p++ {
+		switch text[p-1] {
+		case '\n':
+			lines = append(lines, p)
+		case '\r':
+			if p == len(text) || text[p] != '\n' {
+				lines = append(lines, p)
+			}
+		}
+	}
+	return lines
+}
+
+// Text returns the text of the document.
+func (x *LineIndex) Text() string { return x.text }
+
+// LineCount returns the number of lines in the document. A document
+// whose text ends with a line terminator has an empty last line.
+func (x *LineIndex) LineCount() int { return len(x.lines) }
+
+// Offset returns the byte offset of a position, with the same
+// semantics as PositionToOffset.
+func (x *LineIndex) Offset(pos Position) (int, error) {
+	if pos.Line < 0 || pos.Character < 0 {
+		return 0, fmt.Errorf("lsp: invalid position %s", pos)
+	}
+	if pos.Line >= len(x.lines) {
+		return 0, fmt.Errorf("lsp: position %s is beyond the last line (%d)", pos, len(x.lines)-1)
+	}
+	start := x.lines[pos.Line]
+	end := lineEnd(x.text, start)
+	off, err := columnToOffset(x.text[start:end], pos.Character, x.enc)
+	if err != nil {
+		return 0, fmt.Errorf("lsp: position %s: %s", pos, err)
+	}
+	return start + off, nil
+}
+
+// Position returns the position of a byte offset, with the same
+// semantics as OffsetToPosition.
+func (x *LineIndex) Position(offset int) (Position, error) {
+	if offset < 0 || offset > len(x.text) {
+		return Position{}, fmt.Errorf("lsp: offset %d is out of range [0, %d]", offset, len(x.text))
+	}
+	line := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] > offset }) - 1
+	start := x.lines[line]
+	col, err := offsetToColumn(x.text[start:], offset-start, x.enc)
+	if err != nil {
+		return Position{}, fmt.Errorf("lsp: offset %d: %s", offset, err)
+	}
+	return Position{Line: line, Character: col}, nil
+}
+
+// Apply applies a change to the document. A change without a Range
+// replaces the whole text; otherwise only the line offsets around the
+// changed range are recomputed.
+//
+// Apply is not incremental in cost, however: the text and the line
+// offsets are copied, rather than modified in place, so that copies of
+// the LineIndex (such as those of Snapshots) are unaffected. Each
+// change therefore takes time linear in the size of the document
+// (milliseconds for a document of a few megabytes), even for a
+// one-character change.
+func (x *LineIndex) Apply(change TextDocumentContentChangeEvent) error {
+	if change.Range == nil {
+		*x = *NewLineIndex(change.Text, x.enc)
+		return nil
+	}
+
+	start, err := x.Offset(change.Range.Start)
+	if err != nil {
+		return err
+	}
+	end, err := x.Offset(change.Range.End)
+	if err != nil {
+		return err
+	}
+	if end < start {
+		return fmt.Errorf("lsp: invalid range %s: end is before start", change.Range)
+	}
+
+	text := x.text[:start] + change.Text + x.text[end:]
+	newEnd := start + len(change.Text)
+	delta := newEnd - end
+
+	// Whether a line starts at offset p depends only on the characters
+	// at p-1 and p (to tell "\r\n" from "\r"), so line starts before
+	// start are unchanged, and those after end+1 are only shifted by
+	// delta.
+	head := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] >= start })
+	if head == 0 {
+		head = 1 // line 0 always starts at 0
+	}
+	tail := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] > end+1 })
+	lines := make([]int, 0, len(x.lines)+len(change.Text)/32)
+	lines = append(lines, x.lines[:head]...)
+	lines = appendLineStarts(lines, text, max(start, 1), min(newEnd+1, len(text)))
+	for _, p := range x.lines[tail:] {
+		lines = append(lines, p+delta)
+	}
+
+	x.text = text
+	x.lines = lines
+	return nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,218 @@
+package lsp
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineIndex(t *testing.T) {
+	const text = "a😀b\r\n日本\rx\n\n"
+	x := NewLineIndex(text, PEKUTF16)
+	if want := []int{0, 8, 15, 17, 18}; !reflect.DeepEqual(x.lines, want) {
+		t.Fatalf("got lines %v, want %v", x.lines, want)
+	}
+	if x.LineCount() != 5 {
+		t.Errorf("got %d lines, want 5", x.LineCount())
+	}
+
+	// LineIndex must agree with PositionToOffset and OffsetToPosition.
+	for _, enc := range []PositionEncodingKind{PEKUTF8, PEKUTF16, PEKUTF32} {
+		x := NewLineIndex(text, enc)
+		for offset := -1; offset <= len(text)+1; offset++ {
+			pos, err := x.Position(offset)
+			wantPos, wantErr := OffsetToPosition(text, offset, enc)
+			if pos != wantPos || (err == nil) != (wantErr == nil) {
+				t.Errorf("%s: offset %d: got %s (error %v), want %s (error %v)", enc, offset, pos, err, wantPos, wantErr)
+			}
+		}
+		for line := -1; line <= 6; line++ {
+			for char := 0; char <= 12; char++ {
+				pos := Position{Line: line, Character: char}
+				offset, err := x.Offset(pos)
+				wantOffset, wantErr := PositionToOffset(text, pos, enc)
+				if offset != wantOffset || (err == nil) != (wantErr == nil) {
+					t.Errorf("%s: %s: got %d (error %v), want %d (error %v)", enc, pos, offset, err, wantOffset, wantErr)
+				}
+			}
+		}
+	}
+}
+
+func TestLineIndex_Apply(t *testing.T) {
+	tests := []struct {
+		text   string
+		change TextDocumentContentChangeEvent
+		want   string
+	}{
+		{
+			text:   "abc\ndef",
+			change: TextDocumentContentChangeEvent{Text: "x\ny"},
+			want:   "x\ny",
+		},
+		{
+			text:   "abc\ndef\nghi",
+			change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 1}, End: Position{2, 1}}, Text: "X"},
+			want:   "aXhi",
+		},
+		{
+			text:   "abc\ndef",
+			change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{1, 0}, End: Position{1, 0}}, Text: "1\n2\r\n"},
+			want:   "abc\n1\n2\r\ndef",
+		},
+		{
+			// Joining "\r" and "\n" into a single line terminator.
+			text:   "a\rX\nb",
+			change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{1, 0}, End: Position{1, 1}}},
+			want:   "a\r\nb",
+		},
+		{
+			// Replacing "\r\n" with "\r".
+			text:   "a\r\nb",
+			change: TextDocumentContentChangeEvent{Range: &Range{Start: Position{0, 1}, End: Position{1, 0}}, Text: "\r"},
+			want:   "a\rb",
+		},
+	}
+	for _, test := range tests {
+		x := NewLineIndex(test.text, PEKUTF16)
+		if err := x.Apply(test.change); err != nil {
+			t.Errorf("%q: %s", test.text, err)
+			continue
+		}
+		if x.Text() != test.want {
+			t.Errorf("%q: got text %q, want %q", test.text, x.Text(), test.want)
+		}
+		if want := NewLineIndex(test.want, PEKUTF16).lines; !reflect.DeepEqual(x.lines, want) {
+			t.Errorf("%q: got lines %v, want %v", test.text, x.lines, want)
+		}
+	}
+
+	x := NewLineIndex("abc\ndef", PEKUTF16)
+	for _, r := range []Range{
+		{Start: Position{0, 2}, End: Position{0, 1}},
+		{Start: Position{0, 0}, End: Position{3, 0}},
+	} {
+		r := r
+		if err := x.Apply(TextDocumentContentChangeEvent{Range: &r}); err == nil {
+			t.Errorf("%s: got nil error", r)
+		}
+	}
+}
+
+func TestLineIndex_Apply_random(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "\n", "\r", "\r\n", "😀", "日"}
+	randomText := func(n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(alphabet[rng.Intn(len(alphabet))])
+		}
+		return b.String()
+	}
+	randomPosition := func(x *LineIndex) Position {
+		for {
+			off := rng.Intn(len(x.text) + 1)
+			if pos, err := x.Position(off); err == nil {
+				return pos
+			}
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		x := NewLineIndex(randomText(20), PEKUTF16)
+		for j := 0; j < 20; j++ {
+			start, end := randomPosition(x), randomPosition(x)
+			if end.Line < start.Line || end.Line == start.Line && end.Character < start.Character {
+				start, end = end, start
+			}
+			change := TextDocumentContentChangeEvent{Range: &Range{Start: start, End: end}, Text: randomText(rng.Intn(5))}
+			before := x.text
+			if err := x.Apply(change); err != nil {
+				t.Fatalf("%q: %s: %s", before, change.Range, err)
+			}
+			if want := NewLineIndex(x.text, PEKUTF16).lines; !reflect.DeepEqual(x.lines, want) {
+				t.Fatalf("%q: applying %q at %s: got lines %v, want %v", before, change.Text, change.Range, x.lines, want)
+			}
+		}
+	}
+}
+
+// generateDocument returns a document of about size bytes, with lines
+// of varying lengths containing non-ASCII characters.
+func generateDocument(size int) string {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"func", "return", "x", "😀", "日本語", "// comment", "\t", "if err != nil {", "}"}
+	var b strings.Builder
+	for b.Len() < size {
+		for n := rng.Intn(12); n > 0; n-- {
+			b.WriteString(words[rng.Intn(len(words))])
+			b.WriteByte(' ')
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func BenchmarkNewLineIndex(b *testing.B) {
+	text := generateDocument(4 << 20)
+	b.SetBytes(int64(len(text)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewLineIndex(text, PEKUTF16)
+	}
+}
+
+func BenchmarkLineIndex_Offset(b *testing.B) {
+	text := generateDocument(4 << 20)
+	x := NewLineIndex(text, PEKUTF16)
+	pos := Position{Line: x.LineCount() - 2, Character: 3}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := x.Offset(pos); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLineIndex_Position(b *testing.B) {
+	text := generateDocument(4 << 20)
+	x := NewLineIndex(text, PEKUTF16)
+	offset := x.lines[len(x.lines)-2]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := x.Position(offset); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPositionToOffset is the baseline for
+// BenchmarkLineIndex_Offset, rescanning the text for every conversion.
+func BenchmarkPositionToOffset(b *testing.B) {
+	text := generateDocument(4 << 20)
+	pos := Position{Line: NewLineIndex(text, PEKUTF16).LineCount() - 2, Character: 3}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := PositionToOffset(text, pos, PEKUTF16); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLineIndex_Apply(b *testing.B) {
+	text := generateDocument(4 << 20)
+	x := NewLineIndex(text, PEKUTF16)
+	mid := Position{Line: x.LineCount() / 2}
+	insert := TextDocumentContentChangeEvent{Range: &Range{Start: mid, End: mid}, Text: "x\n"}
+	remove := TextDocumentContentChangeEvent{Range: &Range{Start: mid, End: Position{Line: mid.Line + 1}}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := x.Apply(insert); err != nil {
+			b.Fatal(err)
+		}
+		if err := x.Apply(remove); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package lspext
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// GitURI is a parsed Git workspace URI of the form
+// "git://github.com/facebook/react.git?rev=master#lib", as used by
+// InitializeParams.OriginalRootURI, which identifies a path within a
+// revision of a repository.
+type GitURI struct {
+	// Repo is the repository's clone URL without the scheme, such as
+	// "github.com/facebook/react.git".
+	Repo string
+
+	// Rev is the revision (such as a branch name, tag or commit ID),
+	// which may contain slashes. It may be empty.
+	Rev string
+
+	// Path is the slash-separated path of a file or directory within
+	// the repository, without a leading slash. It is empty for the
+	// root of the repository.
+	Path string
+}
+
+// ParseGitURI parses a Git workspace URI. The revision is taken from
+// the "rev" query parameter; for compatibility with older clients, a
+// query without parameters ("?master") is taken as the revision too.
+func ParseGitURI(uri lsp.DocumentURI) (*GitURI, error) {
+	u, err := url.Parse(string(uri))
+	if err != nil {
+		return nil, fmt.Errorf("lspext: invalid Git URI %q: %s", uri, err)
+	}
+	if u.Scheme != "git" {
+		return nil, fmt.Errorf("lspext: URI %q is not a Git URI", uri)
+	}
+	if u.Host == "" || u.Opaque != "" {
+		return nil, fmt.Errorf("lspext: Git URI %q has no repository", uri)
+	}
+
+	g := &GitURI{Repo: u.Host + strings.TrimSuffix(u.Path, "/")}
+	if u.RawQuery != "" {
+		q, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			return nil, fmt.Errorf("lspext: Git URI %q has an invalid query: %s", uri, err)
+		}
+		if rev, ok := q["rev"]; ok {
+			g.Rev = rev[0]
+		} else if g.Rev, err = url.QueryUnescape(u.RawQuery); err != nil {
+			return nil, fmt.Errorf("lspext: Git URI %q has an invalid revision: %s", uri, err)
+		}
+	}
+	if g.Path, err = cleanRepoPath(u.Fragment); err != nil {
+		return nil, fmt.Errorf("lspext: Git URI %q: %s", uri, err)
+	}
+	return g, nil
+}
+
+// cleanRepoPath returns the clean form of a path within a repository,
+// without a leading slash, or an error if it refers to a location
+// outside of the repository.
+func cleanRepoPath(p string) (string, error) {
+	p = path.Clean(strings.TrimLeft(p, "/"))
+	switch {
+	case p == ".":
+		return "", nil
+	case p == ".." || strings.HasPrefix(p, "../"):
+		return "", fmt.Errorf("path %q is outside of the repository", p)
+	}
+	return p, nil
+}
+
+// String returns the URI form of g.
+func (g *GitURI) String() string {
+	u := url.URL{Scheme: "git", Fragment: g.Path}
+	if i := strings.IndexByte(g.Repo, '/'); i >= 0 {
+		u.Host, u.Path = g.Repo[:i], g.Repo[i:]
+	} else {
+		u.Host = g.Repo
+	}
+	if g.Rev != "" {
+		// Slashes are valid in a query and are common in branch names,
+		// so keep them readable.
+		u.RawQuery = "rev=" + strings.Replace(url.QueryEscape(g.Rev), "%2F", "/", -1)
+	}
+	return u.String()
+}
+
+// URI returns the URI form of g.
+func (g *GitURI) URI() lsp.DocumentURI {
+	return lsp.DocumentURI(g.String())
+}
+
+// ResolvePath returns the URI of the file or directory at path p,
+// relative to g.Path if p is relative, or to the root of the
+// repository if p starts with a slash. It is an error for the result
+// to be outside of the repository.
+func (g *GitURI) ResolvePath(p string) (*GitURI, error) {
+	if !strings.HasPrefix(p, "/") {
+		p = path.Join(g.Path, p)
+	}
+	clean, err := cleanRepoPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("lspext: resolving %q against %s: %s", p, g, err)
+	}
+	return &GitURI{Repo: g.Repo, Rev: g.Rev, Path: clean}, nil
+}
+
+// FileURI returns the file URI of g.Path in a checkout of the
+// repository whose root is at the file URI root.
+func (g *GitURI) FileURI(root lsp.DocumentURI) (lsp.DocumentURI, error) {
+	rootPath, err := root.Path()
+	if err != nil {
+		return "", err
+	}
+	return lsp.URIFromPath(path.Join(rootPath, g.Path)), nil
+}
+
+// FromFileURI is the inverse of FileURI: it returns the URI, in the
+// same repository and revision as g, of the file with URI uri in a
+// checkout of the repository whose root is at the file URI root. It is
+// an error for uri not to be inside root.
+func (g *GitURI) FromFileURI(root, uri lsp.DocumentURI) (*GitURI, error) {
+	if !root.Contains(uri) {
+		return nil, fmt.Errorf("lspext: %s is not inside the workspace root %s", uri, root)
+	}
+	rootPath, err := root.Normalize().Path()
+	if err != nil {
+		return nil, err
+	}
+	p, err := uri.Normalize().Path()
+	if err != nil {
+		return nil, err
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(p, rootPath), "/")
+	return &GitURI{Repo: g.Repo, Rev: g.Rev, Path: rel}, nil
+}
@@ -0,0 +1,122 @@
+package lspext
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+func TestParseGitURI(t *testing.T) {
+	tests := []struct {
+		uri     lsp.DocumentURI
+		want    GitURI
+		wantURI lsp.DocumentURI // if different from uri
+	}{
+		{
+			uri:  "git://github.com/facebook/react.git?rev=master#lib",
+			want: GitURI{Repo: "github.com/facebook/react.git", Rev: "master", Path: "lib"},
+		},
+		{
+			uri:  "git://github.com/facebook/react.git",
+			want: GitURI{Repo: "github.com/facebook/react.git"},
+		},
+		{
+			uri:  "git://github.com/gorilla/mux?rev=feature/foo-bar#mux.go",
+			want: GitURI{Repo: "github.com/gorilla/mux", Rev: "feature/foo-bar", Path: "mux.go"},
+		},
+		{
+			uri:     "git://github.com/gorilla/mux?rev=feature%2Ffoo#a/b",
+			want:    GitURI{Repo: "github.com/gorilla/mux", Rev: "feature/foo", Path: "a/b"},
+			wantURI: "git://github.com/gorilla/mux?rev=feature/foo#a/b",
+		},
+		{
+			uri:  "git://example.com/r?rev=v1%2B2#dir%20with%20space/f%23.go",
+			want: GitURI{Repo: "example.com/r", Rev: "v1+2", Path: "dir with space/f#.go"},
+		},
+		{
+			uri:     "git://github.com/gorilla/mux?release/1.0#/a/./b/",
+			want:    GitURI{Repo: "github.com/gorilla/mux", Rev: "release/1.0", Path: "a/b"},
+			wantURI: "git://github.com/gorilla/mux?rev=release/1.0#a/b",
+		},
+	}
+	for _, test := range tests {
+		got, err := ParseGitURI(test.uri)
+		if err != nil {
+			t.Errorf("%s: %s", test.uri, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.uri, *got, test.want)
+		}
+		wantURI := test.wantURI
+		if wantURI == "" {
+			wantURI = test.uri
+		}
+		if uri := got.URI(); uri != wantURI {
+			t.Errorf("%s: got URI %s, want %s", test.uri, uri, wantURI)
+		}
+	}
+
+	for _, uri := range []lsp.DocumentURI{"file:///a", "git:///a", "git://github.com/a#../b", "git://github.com/a?rev=%zz"} {
+		if got, err := ParseGitURI(uri); err == nil {
+			t.Errorf("%s: got %+v, want error", uri, got)
+		}
+	}
+}
+
+func TestGitURI_ResolvePath(t *testing.T) {
+	base := &GitURI{Repo: "github.com/a/b", Rev: "dev/x", Path: "lib"}
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "x.go", want: "lib/x.go"},
+		{path: "../cmd/main.go", want: "cmd/main.go"},
+		{path: "/README.md", want: "README.md"},
+		{path: "..", want: ""},
+	}
+	for _, test := range tests {
+		got, err := base.ResolvePath(test.path)
+		if err != nil {
+			t.Errorf("%q: %s", test.path, err)
+			continue
+		}
+		if want := (GitURI{Repo: base.Repo, Rev: base.Rev, Path: test.want}); *got != want {
+			t.Errorf("%q: got %+v, want %+v", test.path, *got, want)
+		}
+	}
+	if got, err := base.ResolvePath("../../x"); err == nil {
+		t.Errorf("got %+v, want error", got)
+	}
+}
+
+func TestGitURI_FileURI(t *testing.T) {
+	base := &GitURI{Repo: "github.com/a/b", Rev: "dev/x"}
+	for _, root := range []lsp.DocumentURI{"file:///", "file:///src/my%20repo", "file:///c%3A/src/"} {
+		g := &GitURI{Repo: base.Repo, Rev: base.Rev, Path: "dir/a b.go"}
+		uri, err := g.FileURI(root)
+		if err != nil {
+			t.Errorf("%s: %s", root, err)
+			continue
+		}
+		if !root.Contains(uri) {
+			t.Errorf("%s: %s is not inside root", root, uri)
+		}
+		got, err := base.FromFileURI(root, uri)
+		if err != nil {
+			t.Errorf("%s: %s", root, err)
+			continue
+		}
+		if *got != *g {
+			t.Errorf("%s: got %+v, want %+v", root, *got, *g)
+		}
+	}
+
+	if uri, err := (&GitURI{Path: "x.go"}).FileURI("file:///src"); err != nil || uri != "file:///src/x.go" {
+		t.Errorf("got %s (error %v), want file:///src/x.go", uri, err)
+	}
+	if got, err := base.FromFileURI("file:///src", "file:///srcx/a.go"); err == nil {
+		t.Errorf("got %+v, want error", got)
+	}
+}
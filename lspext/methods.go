@@ -0,0 +1,62 @@
+package lspext
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// The names of the Sourcegraph extension methods.
+const (
+	// MethodXReferences is the request "workspace/xreferences".
+	MethodXReferences = "workspace/xreferences"
+
+	// MethodXDefinition is the request "textDocument/xdefinition".
+	MethodXDefinition = "textDocument/xdefinition"
+
+	// MethodXPackages is the request "workspace/xpackages".
+	MethodXPackages = "workspace/xpackages"
+
+	// MethodXDependencies is the request "workspace/xdependencies".
+	MethodXDependencies = "workspace/xdependencies"
+
+	// MethodXFiles is the request "workspace/xfiles", sent by the
+	// server to a client with the XFilesProvider capability.
+	MethodXFiles = "workspace/xfiles"
+
+	// MethodXContent is the request "textDocument/xcontent", sent by
+	// the server to a client with the XContentProvider capability.
+	MethodXContent = "textDocument/xcontent"
+
+	// MethodCacheGet is the request "cache/get", sent by the server to
+	// a client with the XCacheProvider capability.
+	MethodCacheGet = "cache/get"
+
+	// MethodCacheSet is the notification "cache/set", sent by the
+	// server to a client with the XCacheProvider capability.
+	MethodCacheSet = "cache/set"
+
+	// MethodPartialResult is the notification "$/partialResult".
+	MethodPartialResult = "$/partialResult"
+)
+
+func init() {
+	for _, info := range []lsp.MethodInfo{
+		{Method: MethodXReferences, Direction: lsp.ClientToServer, Params: typeOf((*WorkspaceReferencesParams)(nil)), Result: typeOf((*[]ReferenceInformation)(nil))},
+		{Method: MethodXDefinition, Direction: lsp.ClientToServer, Params: typeOf((*lsp.TextDocumentPositionParams)(nil)), Result: typeOf((*[]SymbolLocationInformation)(nil))},
+		{Method: MethodXPackages, Direction: lsp.ClientToServer, Params: typeOf((*WorkspacePackagesParams)(nil)), Result: typeOf((*[]PackageInformation)(nil))},
+		{Method: MethodXDependencies, Direction: lsp.ClientToServer, Result: typeOf((*[]DependencyReference)(nil))},
+		{Method: MethodXFiles, Direction: lsp.ServerToClient, Params: typeOf((*FilesParams)(nil)), Result: typeOf((*[]lsp.TextDocumentIdentifier)(nil))},
+		{Method: MethodXContent, Direction: lsp.ServerToClient, Params: typeOf((*ContentParams)(nil)), Result: typeOf((*lsp.TextDocumentItem)(nil))},
+		{Method: MethodCacheGet, Direction: lsp.ServerToClient, Params: typeOf((*CacheGetParams)(nil)), Result: typeOf((**json.RawMessage)(nil))},
+		{Method: MethodCacheSet, Direction: lsp.ServerToClient, Notification: true, Params: typeOf((*CacheSetParams)(nil))},
+		{Method: MethodPartialResult, Direction: lsp.ServerToClient, Notification: true, Params: typeOf((*PartialResultParams)(nil))},
+	} {
+		lsp.RegisterMethod(info)
+	}
+}
+
+func typeOf(ptr interface{}) reflect.Type {
+	return reflect.TypeOf(ptr).Elem()
+}
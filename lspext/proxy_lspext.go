@@ -14,7 +14,10 @@
 package lspext
 
 import (
+	"bytes"
+	"encoding/json"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/sourcegraph/go-lsp"
@@ -71,6 +74,31 @@ type InitializeParams struct {
 	Mode string `json:"mode"`
 }
 
+// uriKeys is the set of JSON object keys whose string values are
+// document URIs.
+//
+// InitializeParams.OriginalRootURI ("originalRootUri") is deliberately
+// absent: it records the root URI before any rewriting.
+var uriKeys = map[string]bool{
+	"uri":       true,
+	"rootUri":   true,
+	"scopeUri":  true,
+	"targetUri": true,
+	"oldUri":    true, // RenameFile
+	"newUri":    true,
+}
+
+// uriMapKeys is the set of JSON object keys whose values are objects
+// keyed by document URIs, such as WorkspaceEdit.Changes.
+var uriMapKeys = map[string]bool{
+	"changes": true,
+}
+
+var (
+	documentURIType = reflect.TypeOf(lsp.DocumentURI(""))
+	rawMessageType  = reflect.TypeOf(json.RawMessage(nil))
+)
+
 // WalkURIFields walks the LSP params/result object for fields
 // containing document URIs.
 //
@@ -82,61 +110,194 @@ type InitializeParams struct {
 // params/result with the value of f(existingURI). Callers can use
 // this to rewrite paths in the params/result.
 //
-// TODO(sqs): does not support WorkspaceEdit (with a field whose
-// TypeScript type is {[uri: string]: TextEdit[]}.
+// The object may be a value decoded from JSON into interface{}, or a
+// pointer to a value of the types in the lsp and lspext packages.
+// URIs are found in fields of type lsp.DocumentURI, in values with one
+// of the keys "uri", "rootUri", "scopeUri", "targetUri", "oldUri" and
+// "newUri", and in the keys of WorkspaceEdit.Changes, including inside slices, maps,
+// interface{} values and json.RawMessage values (which are re-encoded
+// only if a URI changes).
 func WalkURIFields(o interface{}, collect func(lsp.DocumentURI), update func(lsp.DocumentURI) lsp.DocumentURI) {
-	var walk func(o interface{})
-	walk = func(o interface{}) {
-		switch o := o.(type) {
-		case map[string]interface{}:
-			for k, v := range o { // Location, TextDocumentIdentifier, TextDocumentItem, etc.
-				if k == "uri" {
-					s, ok := v.(string)
-					if !ok {
-						s2, ok2 := v.(lsp.DocumentURI)
-						s = string(s2)
-						ok = ok2
-					}
-					if ok {
-						if collect != nil {
-							collect(lsp.DocumentURI(s))
-						}
-						if update != nil {
-							o[k] = update(lsp.DocumentURI(s))
-						}
-						continue
-					}
-				}
-				walk(v)
-			}
-		case []interface{}: // Location[]
-			for _, v := range o {
-				walk(v)
+	w := uriWalker{collect: collect, update: update}
+	w.walk(reflect.ValueOf(o))
+}
+
+type uriWalker struct {
+	collect func(lsp.DocumentURI)
+	update  func(lsp.DocumentURI) lsp.DocumentURI
+
+	changed bool // whether update has changed a URI
+}
+
+// uri collects and updates a URI.
+func (w *uriWalker) uri(s string) string {
+	if w.collect != nil {
+		w.collect(lsp.DocumentURI(s))
+	}
+	if w.update != nil {
+		u := string(w.update(lsp.DocumentURI(s)))
+		w.changed = w.changed || u != s
+		return u
+	}
+	return s
+}
+
+// setURI collects and updates the URI in the string value v.
+func (w *uriWalker) setURI(v reflect.Value) {
+	s := w.uri(v.String())
+	if v.CanSet() {
+		v.SetString(s)
+	}
+}
+
+// walk walks v, which must be addressable for its URIs to be updated
+// (except inside maps, which are updated in place).
+func (w *uriWalker) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			w.walk(v.Elem())
+		}
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		w.walkCopy(v.Elem(), func(c reflect.Value) {
+			if v.CanSet() {
+				v.Set(c)
 			}
-		default: // structs with a "URI" field
-			rv := reflect.ValueOf(o)
-			if rv.Kind() == reflect.Ptr {
-				rv = rv.Elem()
+		})
+
+	case reflect.String:
+		if v.Type() == documentURIType {
+			w.setURI(v)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue // unexported
 			}
-			if rv.Kind() == reflect.Struct {
-				if fv := rv.FieldByName("URI"); fv.Kind() == reflect.String {
-					if collect != nil {
-						collect(lsp.DocumentURI(fv.String()))
-					}
-					if update != nil {
-						fv.SetString(string(update(lsp.DocumentURI(fv.String()))))
-					}
-				}
-				for i := 0; i < rv.NumField(); i++ {
-					fv := rv.Field(i)
-					if fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Struct || fv.Kind() == reflect.Array {
-						walk(fv.Interface())
-					}
-				}
+			name := jsonName(f)
+			fv := v.Field(i)
+			switch {
+			case name == "originalRootUri":
+				// Not rewritten; see uriKeys.
+			case uriKeys[name] && fv.Kind() == reflect.String:
+				w.setURI(fv)
+			case uriMapKeys[name] && fv.Kind() == reflect.Map:
+				w.walkMap(fv, true)
+			default:
+				w.walk(fv)
 			}
 		}
+
+	case reflect.Slice, reflect.Array:
+		if v.Type() == rawMessageType {
+			w.walkRawMessage(v)
+			return
+		}
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return // []byte
+		}
+		for i := 0; i < v.Len(); i++ {
+			w.walk(v.Index(i))
+		}
+
+	case reflect.Map:
+		w.walkMap(v, v.Type().Key() == documentURIType)
+	}
+}
+
+// walkCopy walks an addressable copy of v, and passes it to set.
+func (w *uriWalker) walkCopy(v reflect.Value, set func(reflect.Value)) {
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+	w.walk(c)
+	set(c)
+}
+
+// walkMap walks the values of a map, and its keys if uriKeyed.
+// Values with one of uriKeys or uriMapKeys are treated as URIs or maps
+// keyed by URIs, for maps decoded from JSON objects.
+func (w *uriWalker) walkMap(m reflect.Value, uriKeyed bool) {
+	if m.IsNil() || m.Type().Key().Kind() != reflect.String {
+		return
+	}
+	// The entries with updated keys are put in a new map, which then
+	// replaces the contents of m, so that an updated key does not
+	// overwrite an entry that has yet to be walked.
+	updated := m
+	if uriKeyed {
+		updated = reflect.MakeMapWithSize(m.Type(), m.Len())
+	}
+	for _, k := range m.MapKeys() {
+		v := m.MapIndex(k)
+		newKey := k
+		if uriKeyed {
+			newKey = reflect.ValueOf(w.uri(k.String())).Convert(k.Type())
+		}
+		elem := v
+		if elem.Kind() == reflect.Interface && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		switch {
+		case uriKeys[k.String()] && elem.Kind() == reflect.String:
+			v = reflect.ValueOf(w.uri(elem.String())).Convert(elem.Type())
+		case uriMapKeys[k.String()] && elem.Kind() == reflect.Map:
+			w.walkMap(elem, true)
+		default:
+			w.walkCopy(v, func(c reflect.Value) { v = c })
+		}
+		updated.SetMapIndex(newKey, v)
+	}
+	if uriKeyed {
+		for _, k := range m.MapKeys() {
+			m.SetMapIndex(k, reflect.Value{})
+		}
+		for _, k := range updated.MapKeys() {
+			m.SetMapIndex(k, updated.MapIndex(k))
+		}
+	}
+}
+
+// walkRawMessage walks the JSON value encoded in a json.RawMessage,
+// and re-encodes it if a URI is changed. Numbers are decoded as
+// json.Number, so that they are re-encoded exactly.
+func (w *uriWalker) walkRawMessage(v reflect.Value) {
+	if v.Len() == 0 {
+		return
+	}
+	var o interface{}
+	dec := json.NewDecoder(bytes.NewReader(v.Bytes()))
+	dec.UseNumber()
+	if err := dec.Decode(&o); err != nil {
+		return
+	}
+	changed := w.changed
+	w.changed = false
+	w.walk(reflect.ValueOf(&o).Elem())
+	if w.changed && v.CanSet() {
+		if data, err := json.Marshal(o); err == nil {
+			v.SetBytes(data)
+		}
+	}
+	w.changed = w.changed || changed
+}
+
+// jsonName returns the JSON object key of a struct field.
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "" {
+		return f.Name
 	}
-	walk(o)
+	return tag
 }
 
 // ClientProxyInitializeParams are sent by the client to the proxy in
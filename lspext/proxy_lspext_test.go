@@ -2,7 +2,12 @@ package lspext
 
 import (
 	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -11,8 +16,13 @@ import (
 
 func TestWalkURIFields(t *testing.T) {
 	tests := map[string][]lsp.DocumentURI{
-		`{"textDocument":{"uri":"u1"}}`: []lsp.DocumentURI{"u1"},
-		`{"uri":"u1"}`:                  []lsp.DocumentURI{"u1"},
+		`{"textDocument":{"uri":"u1"}}`:                      []lsp.DocumentURI{"u1"},
+		`{"uri":"u1"}`:                                       []lsp.DocumentURI{"u1"},
+		`{"originalRootUri":"git://x","rootUri":"u1"}`:       []lsp.DocumentURI{"u1"},
+		`{"items":[{"scopeUri":"u1"},{"scopeUri":"u2"}]}`:    []lsp.DocumentURI{"u1", "u2"},
+		`[{"targetUri":"u1","uri":"u2"}]`:                    []lsp.DocumentURI{"u1", "u2"},
+		`{"changes":{"u1":[{"newText":"uri"}]},"uri":5}`:     []lsp.DocumentURI{"u1"},
+		`{"data":{"uri":"u2"},"edit":{"changes":{"u1":[]}}}`: []lsp.DocumentURI{"u1", "u2"},
 	}
 	for objStr, wantURIs := range tests {
 		var obj interface{}
@@ -26,6 +36,7 @@ func TestWalkURIFields(t *testing.T) {
 		update := func(uri lsp.DocumentURI) lsp.DocumentURI { return "XXX" }
 		WalkURIFields(obj, collect, update)
 
+		sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
 		if !reflect.DeepEqual(uris, wantURIs) {
 			t.Errorf("%s: got URIs %q, want %q", objStr, uris, wantURIs)
 		}
@@ -43,6 +54,52 @@ func TestWalkURIFields(t *testing.T) {
 			t.Errorf("%s: got obj %q, want %q after updating URI pointers", objStr, gotObj, wantObj)
 		}
 	}
+
+	// Renaming a key to another key of the map does not lose its entry.
+	update := func(uri lsp.DocumentURI) lsp.DocumentURI {
+		return map[lsp.DocumentURI]lsp.DocumentURI{"a": "b", "b": "c"}[uri]
+	}
+	data := []byte(`{"changes":{"a":[{"newText":"A"}],"b":[{"newText":"B"}]}}`)
+
+	var obj interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		t.Fatal(err)
+	}
+	WalkURIFields(obj, nil, update)
+	got, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := RewriteURIs(data, update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	edit := lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{"a": {{NewText: "A"}}, "b": {{NewText: "B"}}}}
+	WalkURIFields(&edit, nil, update)
+	if want := map[string][]lsp.TextEdit{"b": {{NewText: "A"}}, "c": {{NewText: "B"}}}; !reflect.DeepEqual(edit.Changes, want) {
+		t.Errorf("got changes %v, want %v", edit.Changes, want)
+	}
+}
+
+func TestWalkURIFields_rawMessage(t *testing.T) {
+	tests := []struct {
+		update func(lsp.DocumentURI) lsp.DocumentURI
+		want   string
+	}{
+		{update: func(uri lsp.DocumentURI) lsp.DocumentURI { return uri }, want: `{"id":9007199254740993, "uri":"a"}`},
+		{update: func(uri lsp.DocumentURI) lsp.DocumentURI { return "X" + uri }, want: `{"id":9007199254740993,"uri":"Xa"}`},
+	}
+	for _, test := range tests {
+		raw := json.RawMessage(`{"id":9007199254740993, "uri":"a"}`)
+		WalkURIFields(&raw, nil, test.update)
+		if string(raw) != test.want {
+			t.Errorf("got %s, want %s", raw, test.want)
+		}
+	}
 }
 
 func TestWalkURIFields_struct(t *testing.T) {
@@ -61,3 +118,396 @@ func TestWalkURIFields_struct(t *testing.T) {
 		t.Errorf("got %q, want %q", v.URI, want)
 	}
 }
+
+func TestWalkURIFields_structs(t *testing.T) {
+	raw := json.RawMessage(`{"uri":"u3"}`)
+	v := struct {
+		Edit      *lsp.WorkspaceEdit
+		Locations []lsp.Location
+		ByName    map[string]lsp.TextDocumentIdentifier
+		Item      lsp.ConfigurationItem
+		Data      interface{}
+		Value     *json.RawMessage
+	}{
+		Edit:      &lsp.WorkspaceEdit{Changes: map[string][]lsp.TextEdit{"u1": nil}},
+		Locations: []lsp.Location{{URI: "u2"}},
+		ByName:    map[string]lsp.TextDocumentIdentifier{"a": {URI: "u4"}},
+		Item:      lsp.ConfigurationItem{ScopeURI: "u5"},
+		Data:      lsp.Location{URI: "u6"},
+		Value:     &raw,
+	}
+
+	var uris []lsp.DocumentURI
+	collect := func(uri lsp.DocumentURI) { uris = append(uris, uri) }
+	update := func(uri lsp.DocumentURI) lsp.DocumentURI { return "X" + uri }
+	WalkURIFields(&v, collect, update)
+
+	sort.Slice(uris, func(i, j int) bool { return uris[i] < uris[j] })
+	if want := []lsp.DocumentURI{"u1", "u2", "u3", "u4", "u5", "u6"}; !reflect.DeepEqual(uris, want) {
+		t.Errorf("got %v, want %v", uris, want)
+	}
+	if _, ok := v.Edit.Changes["Xu1"]; !ok || len(v.Edit.Changes) != 1 {
+		t.Errorf("got changes %v, want key Xu1", v.Edit.Changes)
+	}
+	if got := v.Locations[0].URI; got != "Xu2" {
+		t.Errorf("got %q, want Xu2", got)
+	}
+	if got := string(*v.Value); got != `{"uri":"Xu3"}` {
+		t.Errorf("got %s, want {\"uri\":\"Xu3\"}", got)
+	}
+	if got := v.ByName["a"].URI; got != "Xu4" {
+		t.Errorf("got %q, want Xu4", got)
+	}
+	if got := v.Item.ScopeURI; got != "Xu5" {
+		t.Errorf("got %q, want Xu5", got)
+	}
+	if got := v.Data.(lsp.Location).URI; got != "Xu6" {
+		t.Errorf("got %q, want Xu6", got)
+	}
+}
+
+// walkedTypes contains a pointer to every struct type in the lsp and
+// lspext packages that can be sent in a message, which
+// TestWalkURIFields_allTypes checks WalkURIFields against.
+var walkedTypes = map[string]interface{}{
+	"lsp.AnnotatedTextEdit":                       &lsp.AnnotatedTextEdit{},
+	"lsp.CallHierarchyIncomingCall":               &lsp.CallHierarchyIncomingCall{},
+	"lsp.CallHierarchyIncomingCallsParams":        &lsp.CallHierarchyIncomingCallsParams{},
+	"lsp.CallHierarchyItem":                       &lsp.CallHierarchyItem{},
+	"lsp.CallHierarchyOutgoingCall":               &lsp.CallHierarchyOutgoingCall{},
+	"lsp.CallHierarchyOutgoingCallsParams":        &lsp.CallHierarchyOutgoingCallsParams{},
+	"lsp.CallHierarchyPrepareParams":              &lsp.CallHierarchyPrepareParams{},
+	"lsp.CancelParams":                            &lsp.CancelParams{},
+	"lsp.ChangeAnnotation":                        &lsp.ChangeAnnotation{},
+	"lsp.ClientCapabilities":                      &lsp.ClientCapabilities{},
+	"lsp.ClientInfo":                              &lsp.ClientInfo{},
+	"lsp.CodeAction":                              &lsp.CodeAction{},
+	"lsp.CodeActionContext":                       &lsp.CodeActionContext{},
+	"lsp.CodeActionDisabled":                      &lsp.CodeActionDisabled{},
+	"lsp.CodeActionOptions":                       &lsp.CodeActionOptions{},
+	"lsp.CodeActionOptionsOrBool":                 &lsp.CodeActionOptionsOrBool{},
+	"lsp.CodeActionParams":                        &lsp.CodeActionParams{},
+	"lsp.CodeLens":                                &lsp.CodeLens{},
+	"lsp.CodeLensOptions":                         &lsp.CodeLensOptions{},
+	"lsp.CodeLensParams":                          &lsp.CodeLensParams{},
+	"lsp.Command":                                 &lsp.Command{},
+	"lsp.CommandOrCodeAction":                     &lsp.CommandOrCodeAction{},
+	"lsp.CompletionContext":                       &lsp.CompletionContext{},
+	"lsp.CompletionItem":                          &lsp.CompletionItem{},
+	"lsp.CompletionList":                          &lsp.CompletionList{},
+	"lsp.CompletionOptions":                       &lsp.CompletionOptions{},
+	"lsp.CompletionParams":                        &lsp.CompletionParams{},
+	"lsp.ConfigurationItem":                       &lsp.ConfigurationItem{},
+	"lsp.ConfigurationParams":                     &lsp.ConfigurationParams{},
+	"lsp.CreateFile":                              &lsp.CreateFile{},
+	"lsp.CreateFileOptions":                       &lsp.CreateFileOptions{},
+	"lsp.DeleteFile":                              &lsp.DeleteFile{},
+	"lsp.DeleteFileOptions":                       &lsp.DeleteFileOptions{},
+	"lsp.Diagnostic":                              &lsp.Diagnostic{},
+	"lsp.DidChangeConfigurationParams":            &lsp.DidChangeConfigurationParams{},
+	"lsp.DidChangeTextDocumentParams":             &lsp.DidChangeTextDocumentParams{},
+	"lsp.DidChangeWatchedFilesParams":             &lsp.DidChangeWatchedFilesParams{},
+	"lsp.DidCloseTextDocumentParams":              &lsp.DidCloseTextDocumentParams{},
+	"lsp.DidOpenTextDocumentParams":               &lsp.DidOpenTextDocumentParams{},
+	"lsp.DidSaveTextDocumentParams":               &lsp.DidSaveTextDocumentParams{},
+	"lsp.DocumentChange":                          &lsp.DocumentChange{},
+	"lsp.DocumentFormattingParams":                &lsp.DocumentFormattingParams{},
+	"lsp.DocumentHighlight":                       &lsp.DocumentHighlight{},
+	"lsp.DocumentOnTypeFormattingOptions":         &lsp.DocumentOnTypeFormattingOptions{},
+	"lsp.DocumentOnTypeFormattingParams":          &lsp.DocumentOnTypeFormattingParams{},
+	"lsp.DocumentRangeFormattingParams":           &lsp.DocumentRangeFormattingParams{},
+	"lsp.DocumentSymbolParams":                    &lsp.DocumentSymbolParams{},
+	"lsp.ExecuteCommandOptions":                   &lsp.ExecuteCommandOptions{},
+	"lsp.ExecuteCommandParams":                    &lsp.ExecuteCommandParams{},
+	"lsp.FileEvent":                               &lsp.FileEvent{},
+	"lsp.FormattingOptions":                       &lsp.FormattingOptions{},
+	"lsp.GeneralClientCapabilities":               &lsp.GeneralClientCapabilities{},
+	"lsp.Hover":                                   &lsp.Hover{},
+	"lsp.InitializeError":                         &lsp.InitializeError{},
+	"lsp.InitializeParams":                        &lsp.InitializeParams{},
+	"lsp.InitializeResult":                        &lsp.InitializeResult{},
+	"lsp.InitializedParams":                       &lsp.InitializedParams{},
+	"lsp.Location":                                &lsp.Location{},
+	"lsp.LogMessageParams":                        &lsp.LogMessageParams{},
+	"lsp.MessageActionItem":                       &lsp.MessageActionItem{},
+	"lsp.None":                                    &lsp.None{},
+	"lsp.OptionalVersionedTextDocumentIdentifier": &lsp.OptionalVersionedTextDocumentIdentifier{},
+	"lsp.ParameterInformation":                    &lsp.ParameterInformation{},
+	"lsp.Position":                                &lsp.Position{},
+	"lsp.PublishDiagnosticsParams":                &lsp.PublishDiagnosticsParams{},
+	"lsp.Range":                                   &lsp.Range{},
+	"lsp.ReferenceContext":                        &lsp.ReferenceContext{},
+	"lsp.ReferenceParams":                         &lsp.ReferenceParams{},
+	"lsp.RenameFile":                              &lsp.RenameFile{},
+	"lsp.RenameFileOptions":                       &lsp.RenameFileOptions{},
+	"lsp.RenameParams":                            &lsp.RenameParams{},
+	"lsp.ResponseError":                           &lsp.ResponseError{},
+	"lsp.SaveOptions":                             &lsp.SaveOptions{},
+	"lsp.SemanticHighlightingInformation":         &lsp.SemanticHighlightingInformation{},
+	"lsp.SemanticHighlightingOptions":             &lsp.SemanticHighlightingOptions{},
+	"lsp.SemanticHighlightingParams":              &lsp.SemanticHighlightingParams{},
+	"lsp.SemanticHighlightingToken":               &lsp.SemanticHighlightingToken{},
+	"lsp.SemanticTokens":                          &lsp.SemanticTokens{},
+	"lsp.SemanticTokensDelta":                     &lsp.SemanticTokensDelta{},
+	"lsp.SemanticTokensDeltaParams":               &lsp.SemanticTokensDeltaParams{},
+	"lsp.SemanticTokensEdit":                      &lsp.SemanticTokensEdit{},
+	"lsp.SemanticTokensFullOptions":               &lsp.SemanticTokensFullOptions{},
+	"lsp.SemanticTokensFullOptionsOrBool":         &lsp.SemanticTokensFullOptionsOrBool{},
+	"lsp.SemanticTokensLegend":                    &lsp.SemanticTokensLegend{},
+	"lsp.SemanticTokensOptions":                   &lsp.SemanticTokensOptions{},
+	"lsp.SemanticTokensOrDelta":                   &lsp.SemanticTokensOrDelta{},
+	"lsp.SemanticTokensParams":                    &lsp.SemanticTokensParams{},
+	"lsp.SemanticTokensRangeParams":               &lsp.SemanticTokensRangeParams{},
+	"lsp.ServerCapabilities":                      &lsp.ServerCapabilities{},
+	"lsp.ShowMessageParams":                       &lsp.ShowMessageParams{},
+	"lsp.ShowMessageRequestParams":                &lsp.ShowMessageRequestParams{},
+	"lsp.SignatureHelp":                           &lsp.SignatureHelp{},
+	"lsp.SignatureHelpOptions":                    &lsp.SignatureHelpOptions{},
+	"lsp.SignatureInformation":                    &lsp.SignatureInformation{},
+	"lsp.SymbolInformation":                       &lsp.SymbolInformation{},
+	"lsp.TextDocumentClientCapabilities":          &lsp.TextDocumentClientCapabilities{},
+	"lsp.TextDocumentContentChangeEvent":          &lsp.TextDocumentContentChangeEvent{},
+	"lsp.TextDocumentEdit":                        &lsp.TextDocumentEdit{},
+	"lsp.TextDocumentIdentifier":                  &lsp.TextDocumentIdentifier{},
+	"lsp.TextDocumentItem":                        &lsp.TextDocumentItem{},
+	"lsp.TextDocumentPositionParams":              &lsp.TextDocumentPositionParams{},
+	"lsp.TextDocumentSyncOptions":                 &lsp.TextDocumentSyncOptions{},
+	"lsp.TextDocumentSyncOptionsOrKind":           &lsp.TextDocumentSyncOptionsOrKind{},
+	"lsp.TextEdit":                                &lsp.TextEdit{},
+	"lsp.TypeHierarchyItem":                       &lsp.TypeHierarchyItem{},
+	"lsp.TypeHierarchyPrepareParams":              &lsp.TypeHierarchyPrepareParams{},
+	"lsp.TypeHierarchySubtypesParams":             &lsp.TypeHierarchySubtypesParams{},
+	"lsp.TypeHierarchySupertypesParams":           &lsp.TypeHierarchySupertypesParams{},
+	"lsp.VersionedTextDocumentIdentifier":         &lsp.VersionedTextDocumentIdentifier{},
+	"lsp.WindowClientCapabilities":                &lsp.WindowClientCapabilities{},
+	"lsp.WorkspaceClientCapabilities":             &lsp.WorkspaceClientCapabilities{},
+	"lsp.WorkspaceEdit":                           &lsp.WorkspaceEdit{},
+	"lsp.WorkspaceSymbolParams":                   &lsp.WorkspaceSymbolParams{},
+
+	"lspext.CacheGetParams":                   &CacheGetParams{},
+	"lspext.CacheSetParams":                   &CacheSetParams{},
+	"lspext.ClientProxyInitializationOptions": &ClientProxyInitializationOptions{},
+	"lspext.ClientProxyInitializeParams":      &ClientProxyInitializeParams{},
+	"lspext.ContentParams":                    &ContentParams{},
+	"lspext.DependencyReference":              &DependencyReference{},
+	"lspext.ExecParams":                       &ExecParams{},
+	"lspext.ExecResult":                       &ExecResult{},
+	"lspext.FileInfo":                         &FileInfo{},
+	"lspext.FilesParams":                      &FilesParams{},
+	"lspext.ImplementationLocation":           &ImplementationLocation{},
+	"lspext.InitializeParams":                 &InitializeParams{},
+	"lspext.PackageInformation":               &PackageInformation{},
+	"lspext.PartialResultParams":              &PartialResultParams{},
+	"lspext.ReferenceInformation":             &ReferenceInformation{},
+	"lspext.SymbolLocationInformation":        &SymbolLocationInformation{},
+	"lspext.TelemetryEventParams":             &TelemetryEventParams{},
+	"lspext.WorkspacePackagesParams":          &WorkspacePackagesParams{},
+	"lspext.WorkspaceReferencesParams":        &WorkspaceReferencesParams{},
+	"lspext.WorkspaceSymbolParams":            &WorkspaceSymbolParams{},
+}
+
+// unwalkedTypes are the struct types in the lsp and lspext packages
+// that are never sent in a message.
+var unwalkedTypes = map[string]bool{
+	"lsp.BatchError":                    true,
+	"lsp.CallGraph":                     true,
+	"lsp.CallGraphEdge":                 true,
+	"lsp.CallGraphNode":                 true,
+	"lsp.CallGraphOptions":              true,
+	"lsp.Canceller":                     true,
+	"lsp.CharsetError":                  true,
+	"lsp.Client":                        true,
+	"lsp.Conn":                          true,
+	"lsp.DiffOptions":                   true,
+	"lsp.DocumentStore":                 true,
+	"lsp.HeaderError":                   true,
+	"lsp.ID":                            true,
+	"lsp.Lifecycle":                     true,
+	"lsp.LineIndex":                     true,
+	"lsp.MessageReader":                 true,
+	"lsp.MessageTooLargeError":          true,
+	"lsp.MessageWriter":                 true,
+	"lsp.MethodInfo":                    true,
+	"lsp.Notification":                  true, // see the message types below
+	"lsp.Request":                       true,
+	"lsp.Response":                      true,
+	"lsp.ScopeTokenType":                true,
+	"lsp.SemanticHighlightingConverter": true,
+	"lsp.SemanticToken":                 true,
+	"lsp.SemanticTokensBuilder":         true,
+	"lsp.Snapshot":                      true,
+	"lsp.UnimplementedLanguageServer":   true,
+	"lspext.GitURI":                     true,
+	"lspext.TypeHierarchyAdapter":       true,
+}
+
+// TestWalkURIFields_allTypes checks that WalkURIFields finds and
+// updates every URI in every type listed in walkedTypes, both when
+// walking a value of the type and when walking its JSON encoding
+// decoded into interface{}, and that walkedTypes and unwalkedTypes
+// list every struct type in the lsp and lspext packages.
+func TestWalkURIFields_allTypes(t *testing.T) {
+	for _, pkg := range []struct{ name, dir string }{{"lsp", ".."}, {"lspext", "."}} {
+		for _, name := range structTypes(t, pkg.name, pkg.dir) {
+			name = pkg.name + "." + name
+			if _, ok := walkedTypes[name]; !ok && !unwalkedTypes[name] {
+				t.Errorf("%s is missing from walkedTypes or unwalkedTypes", name)
+			}
+		}
+	}
+
+	const (
+		oldURI = "file:///old"
+		newURI = "file:///new"
+	)
+	for name, typ := range walkedTypes {
+		filled := reflect.New(reflect.TypeOf(typ).Elem())
+		fillURIs(filled.Elem(), "", oldURI, 0)
+		data, err := json.Marshal(filled.Interface())
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		want := strings.Count(string(data), oldURI)
+
+		// Decode the JSON encoding rather than using the filled value,
+		// so that fields hidden by others with the same key are empty.
+		v := reflect.New(filled.Type().Elem()).Interface()
+		var generic interface{}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		for _, o := range []interface{}{v, generic} {
+			n := 0
+			WalkURIFields(o, func(lsp.DocumentURI) { n++ }, func(lsp.DocumentURI) lsp.DocumentURI { return newURI })
+			if n != want {
+				t.Errorf("%s (%T): got %d URIs, want %d in %s", name, o, n, want, data)
+			}
+			got, err := json.Marshal(o)
+			if err != nil {
+				t.Errorf("%s (%T): %s", name, o, err)
+				continue
+			}
+			if strings.Contains(string(got), oldURI) {
+				t.Errorf("%s (%T): URIs were not updated: %s", name, o, got)
+			}
+		}
+	}
+}
+
+// structTypes returns the names of the exported struct types declared
+// in the non-test files of the package in dir.
+func structTypes(t *testing.T, pkgName, dir string) []string {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, filter, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, ok := pkgs[pkgName]
+	if !ok {
+		t.Fatalf("package %s not found in %s", pkgName, dir)
+	}
+	var names []string
+	for _, f := range pkg.Files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if _, ok := ts.Type.(*ast.StructType); ok && ts.Name.IsExported() {
+					names = append(names, ts.Name.Name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unionFields maps the union types that must have exactly one field
+// set to the field that fillURIs sets.
+var unionFields = map[reflect.Type]string{
+	reflect.TypeOf(lsp.DocumentChange{}):        "RenameFile",
+	reflect.TypeOf(lsp.CommandOrCodeAction{}):   "CodeAction",
+	reflect.TypeOf(lsp.SemanticTokensOrDelta{}): "Delta",
+	reflect.TypeOf(lsp.ID{}):                    "Num",
+}
+
+// fillURIs populates v, setting every URI in it to uri and every other
+// string to a non-URI value. URIs are recognized independently of
+// uriKeys: they are the strings of type lsp.DocumentURI or with a key
+// ending in "uri" or "Uri", and the keys of WorkspaceEdit.Changes. Slices and maps get one element, and
+// interface{} and json.RawMessage values get an object with a URI.
+func fillURIs(v reflect.Value, key, uri string, depth int) {
+	if depth > 12 {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillURIs(v.Elem(), key, uri, depth+1)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(map[string]interface{}{"uri": uri}))
+		}
+	case reflect.String:
+		switch {
+		case key == "originalRootUri":
+			v.SetString("git://example.com/repo")
+		case v.Type() == documentURIType || key == "uri" || strings.HasSuffix(key, "Uri"):
+			v.SetString(uri)
+		default:
+			v.SetString("text")
+		}
+	case reflect.Struct:
+		if name, ok := unionFields[v.Type()]; ok {
+			// Only one of the union's fields may be set.
+			fillURIs(v.FieldByName(name), "", uri, depth+1)
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); v.Field(i).CanSet() {
+				fillURIs(v.Field(i), jsonName(f), uri, depth+1)
+			}
+		}
+	case reflect.Slice:
+		switch {
+		case v.Type() == rawMessageType:
+			v.SetBytes([]byte(`{"uri":"` + uri + `"}`))
+		case v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes([]byte("text"))
+		case unionFields[v.Type().Elem()] != "":
+			// One element for each of the union's fields.
+			n := v.Type().Elem().NumField()
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for i := 0; i < n; i++ {
+				fillURIs(v.Index(i).Field(i), "", uri, depth+1)
+			}
+		default:
+			v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+			fillURIs(v.Index(0), "", uri, depth+1)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return
+		}
+		k := reflect.New(v.Type().Key()).Elem()
+		if v.Type().Key() == documentURIType || key == "changes" {
+			k.SetString(uri)
+		} else {
+			k.SetString("key")
+		}
+		e := reflect.New(v.Type().Elem()).Elem()
+		fillURIs(e, "", uri, depth+1)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(k, e)
+	}
+}
@@ -0,0 +1,329 @@
+package lspext
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// maxRewriteDepth is the maximum nesting of arrays and objects that
+// RewriteURIs accepts.
+const maxRewriteDepth = 10000
+
+// RewriteURIs returns the JSON-encoded LSP params/result data with
+// every document URI replaced by update(uri). It finds URIs in the
+// same places as WalkURIFields does when walking decoded JSON (the
+// string values with one of the keys in uriKeys, and the keys of
+// WorkspaceEdit.Changes), but works on the encoded bytes directly,
+// without decoding the whole value or using reflection.
+//
+// Everything other than the rewritten URIs, including whitespace, is
+// copied unchanged. If no URI changes, data itself is returned.
+func RewriteURIs(data []byte, update func(lsp.DocumentURI) lsp.DocumentURI) ([]byte, error) {
+	r := uriRewriter{data: data, update: update}
+	if err := r.value(rewriteNone, 0); err != nil {
+		return nil, err
+	}
+	r.skipSpace()
+	if r.pos != len(data) {
+		return nil, r.errorf("unexpected data after top-level value")
+	}
+	if r.out == nil {
+		return data, nil
+	}
+	return append(r.out, data[r.last:]...), nil
+}
+
+// rewriteMode describes how a JSON value is rewritten, depending on
+// its key.
+type rewriteMode int
+
+const (
+	rewriteNone    rewriteMode = iota
+	rewriteURI                 // a string value is a URI
+	rewriteURIKeys             // the keys of an object value are URIs
+)
+
+// keyRewriteMode returns the rewriteMode of values with the given key.
+func keyRewriteMode(key []byte) rewriteMode {
+	// The compiler avoids allocating for string(key) in map lookups.
+	switch {
+	case uriKeys[string(key)]:
+		return rewriteURI
+	case uriMapKeys[string(key)]:
+		return rewriteURIKeys
+	}
+	return rewriteNone
+}
+
+type uriRewriter struct {
+	data   []byte
+	pos    int
+	update func(lsp.DocumentURI) lsp.DocumentURI
+
+	out  []byte // the rewritten data[:last], or nil if unchanged so far
+	last int
+}
+
+func (r *uriRewriter) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("lspext: invalid JSON at offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+}
+
+func (r *uriRewriter) skipSpace() {
+	for r.pos < len(r.data) {
+		switch r.data[r.pos] {
+		case ' ', '\t', '\r', '\n':
+			r.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value scans the value at r.pos.
+func (r *uriRewriter) value(mode rewriteMode, depth int) error {
+	if depth > maxRewriteDepth {
+		return r.errorf("exceeded maximum nesting depth")
+	}
+	r.skipSpace()
+	if r.pos == len(r.data) {
+		return r.errorf("unexpected end of data")
+	}
+	switch c := r.data[r.pos]; {
+	case c == '{':
+		return r.object(mode == rewriteURIKeys, depth)
+	case c == '[':
+		return r.array(depth)
+	case c == '"':
+		start := r.pos
+		escaped, err := r.string()
+		if err != nil {
+			return err
+		}
+		if mode == rewriteURI {
+			return r.rewrite(start, r.pos, escaped)
+		}
+		return nil
+	case c == '-' || '0' <= c && c <= '9' || c == 't' || c == 'f' || c == 'n':
+		start := r.pos
+		for r.pos < len(r.data) {
+			switch r.data[r.pos] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return r.literal(start)
+			}
+			r.pos++
+		}
+		return r.literal(start)
+	default:
+		return r.errorf("unexpected character %q", c)
+	}
+}
+
+// literal checks the number, boolean or null in r.data[start:r.pos].
+func (r *uriRewriter) literal(start int) error {
+	switch lit := r.data[start:r.pos]; {
+	case string(lit) == "true", string(lit) == "false", string(lit) == "null":
+		return nil
+	case !validNumber(lit):
+		return r.errorf("invalid literal %q", lit)
+	}
+	return nil
+}
+
+// validNumber reports whether b is a valid JSON number.
+func validNumber(b []byte) bool {
+	i := 0
+	digits := func() bool {
+		start := i
+		for i < len(b) && '0' <= b[i] && b[i] <= '9' {
+			i++
+		}
+		return i > start
+	}
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	if i < len(b) && b[i] == '0' {
+		i++
+	} else if !digits() {
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		i++
+		if !digits() {
+			return false
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		if !digits() {
+			return false
+		}
+	}
+	return i == len(b)
+}
+
+// object scans the object at r.pos, rewriting its keys if uriKeyed.
+func (r *uriRewriter) object(uriKeyed bool, depth int) error {
+	r.pos++ // '{'
+	r.skipSpace()
+	if r.pos < len(r.data) && r.data[r.pos] == '}' {
+		r.pos++
+		return nil
+	}
+	for {
+		r.skipSpace()
+		if r.pos == len(r.data) || r.data[r.pos] != '"' {
+			return r.errorf("expected object key")
+		}
+		start := r.pos
+		escaped, err := r.string()
+		if err != nil {
+			return err
+		}
+
+		key := r.data[start+1 : r.pos-1]
+		if escaped {
+			var s string
+			if err := json.Unmarshal(r.data[start:r.pos], &s); err != nil {
+				return r.errorf("invalid object key: %s", err)
+			}
+			key = []byte(s)
+		}
+		mode := keyRewriteMode(key)
+		if uriKeyed {
+			if err := r.rewrite(start, r.pos, escaped); err != nil {
+				return err
+			}
+		}
+
+		r.skipSpace()
+		if r.pos == len(r.data) || r.data[r.pos] != ':' {
+			return r.errorf("expected ':' after object key")
+		}
+		r.pos++
+		if err := r.value(mode, depth+1); err != nil {
+			return err
+		}
+		r.skipSpace()
+		if r.pos == len(r.data) {
+			return r.errorf("unexpected end of data in object")
+		}
+		switch r.data[r.pos] {
+		case ',':
+			r.pos++
+		case '}':
+			r.pos++
+			return nil
+		default:
+			return r.errorf("expected ',' or '}' in object")
+		}
+	}
+}
+
+// array scans the array at r.pos.
+func (r *uriRewriter) array(depth int) error {
+	r.pos++ // '['
+	r.skipSpace()
+	if r.pos < len(r.data) && r.data[r.pos] == ']' {
+		r.pos++
+		return nil
+	}
+	for {
+		if err := r.value(rewriteNone, depth+1); err != nil {
+			return err
+		}
+		r.skipSpace()
+		if r.pos == len(r.data) {
+			return r.errorf("unexpected end of data in array")
+		}
+		switch r.data[r.pos] {
+		case ',':
+			r.pos++
+		case ']':
+			r.pos++
+			return nil
+		default:
+			return r.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// string scans the string at r.pos, and reports whether it contains
+// escape sequences.
+func (r *uriRewriter) string() (escaped bool, err error) {
+	r.pos++ // '"'
+	for r.pos < len(r.data) {
+		switch c := r.data[r.pos]; {
+		case c == '"':
+			r.pos++
+			return escaped, nil
+		case c == '\\':
+			escaped = true
+			r.pos += 2
+		case c < 0x20:
+			return false, r.errorf("control character in string")
+		default:
+			r.pos++
+		}
+	}
+	return false, r.errorf("unterminated string")
+}
+
+// rewrite replaces the URI in the string r.data[start:end] with its
+// updated value.
+func (r *uriRewriter) rewrite(start, end int, escaped bool) error {
+	var uri string
+	if escaped {
+		if err := json.Unmarshal(r.data[start:end], &uri); err != nil {
+			return fmt.Errorf("lspext: invalid JSON string at offset %d: %s", start, err)
+		}
+	} else {
+		uri = string(r.data[start+1 : end-1])
+	}
+	newURI := string(r.update(lsp.DocumentURI(uri)))
+	if newURI == uri {
+		return nil
+	}
+	if r.out == nil {
+		r.out = make([]byte, 0, len(r.data)+len(r.data)/8)
+	}
+	r.out = append(r.out, r.data[r.last:start]...)
+	r.out = appendJSONString(r.out, newURI)
+	r.last = end
+	return nil
+}
+
+// appendJSONString appends the JSON encoding of s to b.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+			i++
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			i++
+		case c < utf8.RuneSelf:
+			b = append(b, c)
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				b = append(b, `\ufffd`...)
+			} else {
+				b = append(b, s[i:i+size]...)
+			}
+			i += size
+		}
+	}
+	return append(b, '"')
+}
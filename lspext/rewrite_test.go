@@ -0,0 +1,145 @@
+package lspext
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+func prefixURI(uri lsp.DocumentURI) lsp.DocumentURI {
+	return "file:///ws" + lsp.DocumentURI(strings.TrimPrefix(string(uri), "file://"))
+}
+
+func TestRewriteURIs(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: `null`, want: `null`},
+		{data: `{"uri":"file:///a"}`, want: `{"uri":"file:///ws/a"}`},
+		{data: ` [ {"uri" : "file:///a" } , {"uri":5}, {"uri":null} ] `, want: ` [ {"uri" : "file:///ws/a" } , {"uri":5}, {"uri":null} ] `},
+		{data: `{"textDocument":{"uri":"file:///a"},"position":{"line":1,"character":2}}`, want: `{"textDocument":{"uri":"file:///ws/a"},"position":{"line":1,"character":2}}`},
+		{data: `{"rootUri":"file:///r","originalRootUri":"git://x"}`, want: `{"rootUri":"file:///ws/r","originalRootUri":"git://x"}`},
+		{data: `{"items":[{"scopeUri":"file:///a","section":"go"}]}`, want: `{"items":[{"scopeUri":"file:///ws/a","section":"go"}]}`},
+		{data: `{"changes":{"file:///a":[{"newText":"uri"}],"file:///b":[]}}`, want: `{"changes":{"file:///ws/a":[{"newText":"uri"}],"file:///ws/b":[]}}`},
+		{data: `{"text":"{\"uri\":\"file:///a\"}"}`, want: `{"text":"{\"uri\":\"file:///a\"}"}`},
+		{data: `{"uri":"file:///a b"}`, want: `{"uri":"file:///ws/a b"}`},
+		{data: `{"uri":"file:///\"q\""}`, want: `{"uri":"file:///ws/\"q\""}`},
+		{data: `{"a":[1,-2.5e3,true,false,null,{}],"uri":"file:///a"}`, want: `{"a":[1,-2.5e3,true,false,null,{}],"uri":"file:///ws/a"}`},
+	}
+	for _, test := range tests {
+		got, err := RewriteURIs([]byte(test.data), prefixURI)
+		if err != nil {
+			t.Errorf("%s: %s", test.data, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("%s: got %s, want %s", test.data, got, test.want)
+		}
+	}
+
+	for _, data := range []string{``, `{`, `{"uri"}`, `{"uri":"a",}`, `[1 2]`, `"abc`, `{} {}`, `tru`, `{"a":01}`, `[` + strings.Repeat(`[`, maxRewriteDepth) + `]`} {
+		if got, err := RewriteURIs([]byte(data), prefixURI); err == nil {
+			t.Errorf("%s: got %s, want error", data, got)
+		}
+	}
+}
+
+func TestRewriteURIs_unchanged(t *testing.T) {
+	data := []byte(`{"uri":"file:///a"}`)
+	got, err := RewriteURIs(data, func(uri lsp.DocumentURI) lsp.DocumentURI { return uri })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &got[0] != &data[0] {
+		t.Error("got a copy of unchanged data")
+	}
+}
+
+// TestRewriteURIs_allTypes checks that RewriteURIs rewrites the same
+// URIs as WalkURIFields in the JSON encoding of every type listed in
+// walkedTypes.
+func TestRewriteURIs_allTypes(t *testing.T) {
+	for name, typ := range walkedTypes {
+		v := reflect.New(reflect.TypeOf(typ).Elem())
+		fillURIs(v.Elem(), "", "file:///old", 0)
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+
+		var want interface{}
+		if err := json.Unmarshal(data, &want); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		WalkURIFields(want, nil, prefixURI)
+
+		rewritten, err := RewriteURIs(data, prefixURI)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		var got interface{}
+		if err := json.Unmarshal(rewritten, &got); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %s, want %v", name, rewritten, want)
+		}
+	}
+}
+
+// referencesResult returns the JSON encoding of a textDocument/references
+// result of about size bytes.
+func referencesResult(size int) []byte {
+	var locs []lsp.Location
+	for n := 0; n < size; n += 120 {
+		locs = append(locs, lsp.Location{
+			URI:   lsp.DocumentURI(fmt.Sprintf("file:///src/github.com/org/repo/pkg%d/file%d.go", n%97, n%13)),
+			Range: lsp.Range{Start: lsp.Position{Line: n % 1000, Character: 4}, End: lsp.Position{Line: n % 1000, Character: 12}},
+		})
+	}
+	data, err := json.Marshal(locs)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func BenchmarkRewriteURIs(b *testing.B) {
+	data := referencesResult(4 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := RewriteURIs(data, prefixURI); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWalkURIFields_JSON is the baseline for BenchmarkRewriteURIs:
+// decoding, walking with WalkURIFields and re-encoding.
+func BenchmarkWalkURIFields_JSON(b *testing.B) {
+	data := referencesResult(4 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var o interface{}
+		if err := json.Unmarshal(data, &o); err != nil {
+			b.Fatal(err)
+		}
+		WalkURIFields(o, nil, prefixURI)
+		if _, err := json.Marshal(o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
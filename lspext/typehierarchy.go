@@ -0,0 +1,203 @@
+package lspext
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// TypeHierarchyAdapter implements the type hierarchy requests for a
+// language server that supports only textDocument/implementation with
+// ImplementationLocation results, as Go language servers based on guru
+// do. A proxy can forward the type hierarchy requests of a client to
+// it.
+//
+// The supertypes of a type are the interfaces that it implements (the
+// locations of type "from"), and its subtypes are the types that
+// implement it, if it is an interface (the locations of type "to").
+type TypeHierarchyAdapter struct {
+	// Files, if non-nil, provides the text of documents, from which
+	// the names of types are read. Otherwise, items are named after
+	// their location.
+	Files lsp.FileStore
+
+	// Encoding is the position encoding used with the server. If
+	// empty, UTF-16 is used.
+	Encoding lsp.PositionEncodingKind
+
+	caller lsp.Caller
+}
+
+// NewTypeHierarchyAdapter returns a TypeHierarchyAdapter that sends
+// textDocument/implementation requests to the server using c.
+func NewTypeHierarchyAdapter(c lsp.Caller) *TypeHierarchyAdapter {
+	return &TypeHierarchyAdapter{caller: c}
+}
+
+// PrepareTypeHierarchy handles the request
+// "textDocument/prepareTypeHierarchy". It returns no item if the server
+// reports no implementation relationships at the position or, if Files
+// is set, if there is no identifier at the position.
+func (a *TypeHierarchyAdapter) PrepareTypeHierarchy(ctx context.Context, params *lsp.TypeHierarchyPrepareParams) ([]lsp.TypeHierarchyItem, error) {
+	locs, err := a.implementation(ctx, params.TextDocument.URI, params.Position)
+	if err != nil || len(locs) == 0 {
+		return nil, err
+	}
+
+	item := lsp.TypeHierarchyItem{
+		Name:           locationName(params.TextDocument.URI, params.Position),
+		Kind:           lsp.SKClass,
+		URI:            params.TextDocument.URI,
+		Range:          lsp.Range{Start: params.Position, End: params.Position},
+		SelectionRange: lsp.Range{Start: params.Position, End: params.Position},
+	}
+	if a.Files != nil {
+		r, name, err := a.identifierAt(params.TextDocument.URI, params.Position)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			return nil, nil
+		}
+		item.Name, item.Range, item.SelectionRange = name, r, r
+	}
+	for _, loc := range locs {
+		switch {
+		case loc.Method:
+			item.Kind = lsp.SKMethod
+		case loc.Type == "to":
+			// Only interfaces have types assignable to them.
+			item.Kind = lsp.SKInterface
+		}
+	}
+	return []lsp.TypeHierarchyItem{item}, nil
+}
+
+// Supertypes handles the request "typeHierarchy/supertypes".
+func (a *TypeHierarchyAdapter) Supertypes(ctx context.Context, params *lsp.TypeHierarchySupertypesParams) ([]lsp.TypeHierarchyItem, error) {
+	return a.related(ctx, &params.Item, "from", lsp.SKInterface)
+}
+
+// Subtypes handles the request "typeHierarchy/subtypes".
+func (a *TypeHierarchyAdapter) Subtypes(ctx context.Context, params *lsp.TypeHierarchySubtypesParams) ([]lsp.TypeHierarchyItem, error) {
+	return a.related(ctx, &params.Item, "to", lsp.SKClass)
+}
+
+// related returns the items of the implementation locations of item
+// with the given type, giving them kind (unless they are methods).
+func (a *TypeHierarchyAdapter) related(ctx context.Context, item *lsp.TypeHierarchyItem, typ string, kind lsp.SymbolKind) ([]lsp.TypeHierarchyItem, error) {
+	locs, err := a.implementation(ctx, item.URI, item.SelectionRange.Start)
+	if err != nil {
+		return nil, err
+	}
+	items := []lsp.TypeHierarchyItem{}
+	for _, loc := range locs {
+		if loc.Type != typ {
+			continue
+		}
+		related := lsp.TypeHierarchyItem{
+			Name:           locationName(loc.URI, loc.Range.Start),
+			Kind:           kind,
+			URI:            loc.URI,
+			Range:          loc.Range,
+			SelectionRange: loc.Range,
+		}
+		if loc.Method {
+			related.Kind = lsp.SKMethod
+		}
+		if a.Files != nil {
+			name, err := a.textAt(loc.URI, loc.Range)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				related.Name = name
+			}
+		}
+		items = append(items, related)
+	}
+	return items, nil
+}
+
+func (a *TypeHierarchyAdapter) implementation(ctx context.Context, uri lsp.DocumentURI, pos lsp.Position) ([]ImplementationLocation, error) {
+	var locs []ImplementationLocation
+	err := a.caller.Call(ctx, lsp.MethodImplementation, &lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     pos,
+	}, &locs)
+	return locs, err
+}
+
+// identifierAt returns the range and text of the identifier at pos,
+// or an empty name if there is none.
+func (a *TypeHierarchyAdapter) identifierAt(uri lsp.DocumentURI, pos lsp.Position) (lsp.Range, string, error) {
+	text, err := a.Files.ReadFile(uri)
+	if err != nil {
+		return lsp.Range{}, "", err
+	}
+	offset, err := lsp.PositionToOffset(text, pos, a.Encoding)
+	if err != nil {
+		return lsp.Range{}, "", err
+	}
+	start, end := offset, offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isIdentifierRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isIdentifierRune(r) {
+			break
+		}
+		end += size
+	}
+	if start == end {
+		return lsp.Range{}, "", nil
+	}
+
+	var r lsp.Range
+	if r.Start, err = lsp.OffsetToPosition(text, start, a.Encoding); err != nil {
+		return lsp.Range{}, "", err
+	}
+	if r.End, err = lsp.OffsetToPosition(text, end, a.Encoding); err != nil {
+		return lsp.Range{}, "", err
+	}
+	return r, text[start:end], nil
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// textAt returns the text of the document with the given URI in r.
+func (a *TypeHierarchyAdapter) textAt(uri lsp.DocumentURI, r lsp.Range) (string, error) {
+	text, err := a.Files.ReadFile(uri)
+	if err != nil {
+		return "", err
+	}
+	start, err := lsp.PositionToOffset(text, r.Start, a.Encoding)
+	if err != nil {
+		return "", err
+	}
+	end, err := lsp.PositionToOffset(text, r.End, a.Encoding)
+	if err != nil {
+		return "", err
+	}
+	if end < start {
+		return "", fmt.Errorf("lspext: invalid range %s in %s", r, uri)
+	}
+	return text[start:end], nil
+}
+
+// locationName returns the name of an item at a position for which no
+// better name is known, such as "a.go:3:5".
+func locationName(uri lsp.DocumentURI, pos lsp.Position) string {
+	return fmt.Sprintf("%s:%d:%d", path.Base(string(uri)), pos.Line+1, pos.Character+1)
+}
@@ -0,0 +1,125 @@
+package lspext
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// implementationCaller answers textDocument/implementation requests
+// with the locations for the given positions.
+type implementationCaller map[lsp.Position][]ImplementationLocation
+
+func (c implementationCaller) Call(ctx context.Context, method string, params, result interface{}) error {
+	if method != lsp.MethodImplementation {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	data, err := json.Marshal(c[params.(*lsp.TextDocumentPositionParams).Position])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (c implementationCaller) Notify(ctx context.Context, method string, params interface{}) error {
+	return fmt.Errorf("unexpected notification %s", method)
+}
+
+type mapFileStore map[lsp.DocumentURI]string
+
+func (m mapFileStore) ReadFile(uri lsp.DocumentURI) (string, error) {
+	text, ok := m[uri]
+	if !ok {
+		return "", fmt.Errorf("%s: file does not exist", uri)
+	}
+	return text, nil
+}
+
+func (m mapFileStore) WriteFile(uri lsp.DocumentURI, text string) error {
+	m[uri] = text
+	return nil
+}
+
+func TestTypeHierarchyAdapter(t *testing.T) {
+	const uri = "file:///src/a.go"
+	files := mapFileStore{uri: "package a\n\ntype Reader interface{}\n\ntype File struct{}\n\ntype Closer interface{}\n"}
+	rng := func(line, start, end int) lsp.Range {
+		return lsp.Range{Start: lsp.Position{Line: line, Character: start}, End: lsp.Position{Line: line, Character: end}}
+	}
+	loc := func(r lsp.Range, typ string) ImplementationLocation {
+		return ImplementationLocation{Location: lsp.Location{URI: uri, Range: r}, Type: typ}
+	}
+	readerRange, fileRange, closerRange := rng(2, 5, 11), rng(4, 5, 9), rng(6, 5, 11)
+	caller := implementationCaller{
+		readerRange.Start: {loc(fileRange, "to")},
+		fileRange.Start:   {loc(readerRange, "from"), loc(closerRange, "from")},
+		closerRange.Start: {loc(fileRange, "to")},
+	}
+	// The server is also queried inside identifiers.
+	caller[lsp.Position{Line: 4, Character: 7}] = caller[fileRange.Start]
+	ctx := context.Background()
+
+	a := NewTypeHierarchyAdapter(caller)
+	a.Files = files
+	items, err := a.PrepareTypeHierarchy(ctx, &lsp.TypeHierarchyPrepareParams{TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     lsp.Position{Line: 4, Character: 7},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := lsp.TypeHierarchyItem{Name: "File", Kind: lsp.SKClass, URI: uri, Range: fileRange, SelectionRange: fileRange}
+	if want := []lsp.TypeHierarchyItem{file}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+
+	supertypes, err := a.Supertypes(ctx, &lsp.TypeHierarchySupertypesParams{Item: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []lsp.TypeHierarchyItem{
+		{Name: "Reader", Kind: lsp.SKInterface, URI: uri, Range: readerRange, SelectionRange: readerRange},
+		{Name: "Closer", Kind: lsp.SKInterface, URI: uri, Range: closerRange, SelectionRange: closerRange},
+	}
+	if !reflect.DeepEqual(supertypes, want) {
+		t.Errorf("got supertypes %+v, want %+v", supertypes, want)
+	}
+
+	subtypes, err := a.Subtypes(ctx, &lsp.TypeHierarchySubtypesParams{Item: supertypes[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []lsp.TypeHierarchyItem{file}; !reflect.DeepEqual(subtypes, want) {
+		t.Errorf("got subtypes %+v, want %+v", subtypes, want)
+	}
+	if subtypes, err := a.Subtypes(ctx, &lsp.TypeHierarchySubtypesParams{Item: file}); err != nil || len(subtypes) != 0 {
+		t.Errorf("got subtypes %+v (error %v) of a struct, want none", subtypes, err)
+	}
+
+	// Without Files, items are named after their location.
+	a = NewTypeHierarchyAdapter(caller)
+	items, err = a.PrepareTypeHierarchy(ctx, &lsp.TypeHierarchyPrepareParams{TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+		Position:     readerRange.Start,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := lsp.Range{Start: readerRange.Start, End: readerRange.Start}
+	if want := []lsp.TypeHierarchyItem{{Name: "a.go:3:6", Kind: lsp.SKInterface, URI: uri, Range: start, SelectionRange: start}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+
+	// There is no type hierarchy where the server reports no
+	// implementation relationships.
+	items, err = a.PrepareTypeHierarchy(ctx, &lsp.TypeHierarchyPrepareParams{TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: uri},
+	}})
+	if err != nil || items != nil {
+		t.Errorf("got %+v (error %v), want none", items, err)
+	}
+}
@@ -0,0 +1,217 @@
+// Package lsptest provides an in-memory harness for testing language
+// servers built on package lsp.
+//
+// A Session connects an lsp.Client to a server's lsp.Handler through
+// an in-process pipe, performs the initialize handshake, and records
+// every message exchanged. The handler is created with the server's
+// side of the connection, through which the server can send requests
+// and notifications to the client:
+//
+//	s := lsptest.New(t, func(conn *lsp.Conn) lsp.Handler {
+//		return lsp.NewServerHandler(&myServer{conn: conn})
+//	}, nil)
+//	s.Open("file:///a.go", "go", "package a")
+//	hover, err := s.Client.Hover(ctx, lsp.TextDocumentPositionParams{...})
+package lsptest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+// Options configures a Session.
+type Options struct {
+	// RootURI is the rootUri sent in the initialize request.
+	RootURI lsp.DocumentURI
+
+	// Capabilities are the client capabilities sent in the initialize
+	// request.
+	Capabilities lsp.ClientCapabilities
+
+	// InitializationOptions are the initializationOptions sent in the
+	// initialize request.
+	InitializationOptions interface{}
+
+	// ClientHandler, if non-nil, handles the requests and
+	// notifications sent by the server to the client. If nil, the
+	// server's requests fail with lsp.CodeMethodNotFound and its
+	// notifications are only recorded.
+	ClientHandler lsp.Handler
+}
+
+// RecordedMessage is a message exchanged between the client and the
+// server of a Session.
+type RecordedMessage struct {
+	// Direction is lsp.ClientToServer or lsp.ServerToClient.
+	Direction lsp.Direction
+
+	Message lsp.Message
+}
+
+// Session is a client connected to a language server through an
+// in-memory pipe.
+type Session struct {
+	// Client calls the methods of the server.
+	Client *lsp.Client
+
+	// ClientConn is the client's side of the connection, which can be
+	// used to call methods that Client does not define.
+	ClientConn *lsp.Conn
+
+	// ServerConn is the server's side of the connection, which the
+	// server can use to send requests and notifications to the client.
+	ServerConn *lsp.Conn
+
+	// InitializeResult is the server's result for the initialize
+	// request.
+	InitializeResult *lsp.InitializeResult
+
+	t testing.TB
+
+	mu       sync.Mutex
+	messages []RecordedMessage
+	versions map[lsp.DocumentURI]int
+	closed   bool
+}
+
+// New connects a client to the server handler returned by newHandler,
+// and performs the initialize handshake (the initialize request and
+// initialized notification). newHandler is called with ServerConn
+// before the handshake, so the server can use the connection while
+// handling it. If opts is nil, the zero Options are used. The session
+// is closed (with the shutdown request and exit notification) when the
+// test completes. New calls t.Fatal if the handshake fails.
+func New(t testing.TB, newHandler func(*lsp.Conn) lsp.Handler, opts *Options) *Session {
+	t.Helper()
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	s := &Session{t: t, versions: map[lsp.DocumentURI]int{}}
+	clientStream, serverStream := net.Pipe()
+	ctx := context.Background()
+	h := &serverHandler{}
+	s.ServerConn = lsp.NewConn(ctx, serverStream, h)
+	// Nothing is received before the initialize request is sent below.
+	h.Handler = newHandler(s.ServerConn)
+	if h.Handler == nil {
+		s.ServerConn.Close()
+		clientStream.Close()
+		t.Fatal("lsptest: newHandler returned nil")
+	}
+	s.ClientConn = lsp.NewConn(ctx, clientStream, opts.ClientHandler,
+		lsp.OnSend(func(msg lsp.Message) { s.record(lsp.ClientToServer, msg) }),
+		lsp.OnRecv(func(msg lsp.Message) { s.record(lsp.ServerToClient, msg) }),
+	)
+	s.Client = lsp.NewClient(s.ClientConn)
+	t.Cleanup(s.Close)
+
+	result, err := s.Client.Initialize(ctx, lsp.InitializeParams{
+		RootURI:               opts.RootURI,
+		Capabilities:          opts.Capabilities,
+		InitializationOptions: opts.InitializationOptions,
+	})
+	if err != nil {
+		t.Fatalf("initialize: %s", err)
+	}
+	s.InitializeResult = result
+	if err := s.Client.Initialized(ctx, lsp.InitializedParams{}); err != nil {
+		t.Fatalf("initialized: %s", err)
+	}
+	return s
+}
+
+// serverHandler is the handler of ServerConn, which is set once the
+// connection exists.
+type serverHandler struct {
+	lsp.Handler
+}
+
+func (s *Session) record(dir lsp.Direction, msg lsp.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, RecordedMessage{Direction: dir, Message: msg})
+}
+
+// Messages returns the messages exchanged so far, in the order in
+// which the client sent or received them.
+func (s *Session) Messages() []RecordedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedMessage(nil), s.messages...)
+}
+
+// Notifications returns the notifications with the given method that
+// the server has sent to the client so far.
+func (s *Session) Notifications(method string) []*lsp.Notification {
+	var notifs []*lsp.Notification
+	for _, m := range s.Messages() {
+		if n, ok := m.Message.(*lsp.Notification); ok && m.Direction == lsp.ServerToClient && n.Method == method {
+			notifs = append(notifs, n)
+		}
+	}
+	return notifs
+}
+
+// Open sends a textDocument/didOpen notification for a document with
+// version 1. It calls t.Fatal if the notification cannot be sent.
+func (s *Session) Open(uri lsp.DocumentURI, languageID, text string) {
+	s.t.Helper()
+	s.mu.Lock()
+	s.versions[uri] = 1
+	s.mu.Unlock()
+	if err := s.Client.DidOpen(context.Background(), lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: languageID, Version: 1, Text: text},
+	}); err != nil {
+		s.t.Fatalf("didOpen %s: %s", uri, err)
+	}
+}
+
+// Change sends a textDocument/didChange notification that replaces the
+// full text of a document opened with Open, incrementing its version.
+// It calls t.Fatal if the notification cannot be sent.
+func (s *Session) Change(uri lsp.DocumentURI, text string) {
+	s.t.Helper()
+	s.mu.Lock()
+	s.versions[uri]++
+	version := s.versions[uri]
+	s.mu.Unlock()
+	if err := s.Client.DidChange(context.Background(), lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: uri},
+			Version:                version,
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{{Text: text}},
+	}); err != nil {
+		s.t.Fatalf("didChange %s: %s", uri, err)
+	}
+}
+
+// Close sends the shutdown request and exit notification, and closes
+// the connection. A shutdown request that the server does not
+// implement is not an error. Close is called automatically when the
+// test completes, and does nothing if the session is already closed.
+func (s *Session) Close() {
+	s.mu.Lock()
+	closed := s.closed
+	s.closed = true
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+
+	ctx := context.Background()
+	if err := s.Client.Shutdown(ctx); err != nil && !errors.Is(err, lsp.CodeMethodNotFound) {
+		s.t.Errorf("shutdown: %s", err)
+	}
+	if err := s.Client.Exit(ctx); err != nil {
+		s.t.Errorf("exit: %s", err)
+	}
+	s.ClientConn.Close()
+	s.ServerConn.Close()
+}
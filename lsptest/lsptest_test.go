@@ -0,0 +1,202 @@
+package lsptest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/go-lsp"
+)
+
+type server struct {
+	lsp.UnimplementedLanguageServer
+
+	mu           sync.Mutex
+	capabilities lsp.ClientCapabilities
+	docs         map[lsp.DocumentURI]string
+	shutdown     bool
+}
+
+func (s *server) Initialize(ctx context.Context, params *lsp.InitializeParams) (*lsp.InitializeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.capabilities = params.Capabilities
+	s.docs = map[lsp.DocumentURI]string{}
+	return &lsp.InitializeResult{Capabilities: lsp.ServerCapabilities{HoverProvider: true}}, nil
+}
+
+func (s *server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shutdown = true
+	return nil
+}
+
+func (s *server) DidOpen(ctx context.Context, params *lsp.DidOpenTextDocumentParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[params.TextDocument.URI] = params.TextDocument.Text
+	return nil
+}
+
+func (s *server) DidChange(ctx context.Context, params *lsp.DidChangeTextDocumentParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[params.TextDocument.URI] = params.ContentChanges[0].Text
+	return nil
+}
+
+func (s *server) Hover(ctx context.Context, params *lsp.TextDocumentPositionParams) (*lsp.Hover, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &lsp.Hover{Contents: []lsp.MarkedString{lsp.RawMarkedString(s.docs[params.TextDocument.URI])}}, nil
+}
+
+func TestSession(t *testing.T) {
+	srv := &server{}
+	var caps lsp.ClientCapabilities
+	caps.TextDocument.Completion.ContextSupport = true
+	s := New(t, func(*lsp.Conn) lsp.Handler { return lsp.NewServerHandler(srv) }, &Options{Capabilities: caps})
+
+	if !s.InitializeResult.Capabilities.HoverProvider {
+		t.Error("got HoverProvider == false")
+	}
+	if !srv.capabilities.TextDocument.Completion.ContextSupport {
+		t.Error("client capabilities were not sent")
+	}
+
+	ctx := context.Background()
+	hover := func() string {
+		h, err := s.Client.Hover(ctx, lsp.TextDocumentPositionParams{TextDocument: lsp.TextDocumentIdentifier{URI: "file:///a.go"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h.Contents[0].Value
+	}
+	s.Open("file:///a.go", "go", "package a")
+	if got, want := hover(), "package a"; got != want {
+		t.Errorf("got hover %q, want %q", got, want)
+	}
+	s.Change("file:///a.go", "package b")
+	if got, want := hover(), "package b"; got != want {
+		t.Errorf("got hover %q, want %q", got, want)
+	}
+
+	s.Close()
+	if !srv.shutdown {
+		t.Error("server was not shut down")
+	}
+
+	type message struct {
+		dir    lsp.Direction
+		method string
+	}
+	var got []message
+	for _, m := range s.Messages() {
+		switch msg := m.Message.(type) {
+		case *lsp.Request:
+			got = append(got, message{m.Direction, msg.Method})
+		case *lsp.Notification:
+			got = append(got, message{m.Direction, msg.Method})
+		case *lsp.Response:
+			got = append(got, message{m.Direction, "response"})
+		}
+	}
+	want := []message{
+		{lsp.ClientToServer, lsp.MethodInitialize},
+		{lsp.ServerToClient, "response"},
+		{lsp.ClientToServer, lsp.MethodInitialized},
+		{lsp.ClientToServer, lsp.MethodDidOpen},
+		{lsp.ClientToServer, lsp.MethodHover},
+		{lsp.ServerToClient, "response"},
+		{lsp.ClientToServer, lsp.MethodDidChange},
+		{lsp.ClientToServer, lsp.MethodHover},
+		{lsp.ServerToClient, "response"},
+		{lsp.ClientToServer, lsp.MethodShutdown},
+		{lsp.ServerToClient, "response"},
+		{lsp.ClientToServer, lsp.MethodExit},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got messages %v, want %v", got, want)
+	}
+}
+
+type diagnosticsServer struct {
+	lsp.UnimplementedLanguageServer
+	conn *lsp.Conn
+}
+
+func (s *diagnosticsServer) Initialize(ctx context.Context, params *lsp.InitializeParams) (*lsp.InitializeResult, error) {
+	return &lsp.InitializeResult{}, nil
+}
+
+func (s *diagnosticsServer) Initialized(ctx context.Context, params *lsp.InitializedParams) error {
+	return s.conn.Notify(ctx, lsp.MethodLogMessage, lsp.LogMessageParams{Type: lsp.Info, Message: "initialized"})
+}
+
+func (s *diagnosticsServer) DidOpen(ctx context.Context, params *lsp.DidOpenTextDocumentParams) error {
+	return s.conn.Notify(ctx, lsp.MethodPublishDiagnostics, lsp.PublishDiagnosticsParams{URI: params.TextDocument.URI})
+}
+
+// notificationRecorder is a client handler that sends the
+// notifications it receives on a channel.
+type notificationRecorder chan *lsp.Notification
+
+func (r notificationRecorder) HandleRequest(ctx context.Context, req *lsp.Request) (interface{}, error) {
+	return nil, lsp.NewError(lsp.CodeMethodNotFound, req.Method)
+}
+
+func (r notificationRecorder) HandleNotification(ctx context.Context, n *lsp.Notification) error {
+	r <- n
+	return nil
+}
+
+func TestSession_serverToClient(t *testing.T) {
+	notifs := make(notificationRecorder, 1)
+	s := New(t, func(conn *lsp.Conn) lsp.Handler {
+		return lsp.NewServerHandler(&diagnosticsServer{conn: conn})
+	}, &Options{ClientHandler: notifs})
+
+	// The server can notify the client during the handshake.
+	if n := <-notifs; n.Method != lsp.MethodLogMessage {
+		t.Errorf("got notification %q, want %q", n.Method, lsp.MethodLogMessage)
+	}
+
+	s.Open("file:///a.go", "go", "package a")
+	if n := <-notifs; n.Method != lsp.MethodPublishDiagnostics {
+		t.Errorf("got notification %q, want %q", n.Method, lsp.MethodPublishDiagnostics)
+	}
+	if got := s.Notifications(lsp.MethodPublishDiagnostics); len(got) != 1 {
+		t.Errorf("got %d recorded notifications, want 1", len(got))
+	}
+}
+
+// fatalRecorder is a testing.TB that records the message of a call to
+// Fatal.
+type fatalRecorder struct {
+	testing.TB
+	fatal string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatal(args ...interface{}) {
+	r.fatal = fmt.Sprint(args...)
+	runtime.Goexit()
+}
+
+func TestNew_nilHandler(t *testing.T) {
+	r := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		New(r, func(*lsp.Conn) lsp.Handler { return nil }, nil)
+	}()
+	<-done
+	if want := "lsptest: newHandler returned nil"; r.fatal != want {
+		t.Errorf("got fatal error %q, want %q", r.fatal, want)
+	}
+}
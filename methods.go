@@ -0,0 +1,92 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Direction is the direction in which the messages of an LSP method
+// are sent.
+type Direction int
+
+const (
+	ClientToServer Direction = 1
+	ServerToClient Direction = 2
+	BothDirections Direction = ClientToServer | ServerToClient
+)
+
+func (d Direction) String() string {
+	switch d {
+	case ClientToServer:
+		return "client->server"
+	case ServerToClient:
+		return "server->client"
+	case BothDirections:
+		return "both"
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// MethodInfo describes an LSP method.
+type MethodInfo struct {
+	// Method is the method name, such as "textDocument/hover".
+	Method string
+
+	// Direction is the direction in which the method's requests or
+	// notifications are sent.
+	Direction Direction
+
+	// Notification is whether the method is a notification (as
+	// opposed to a request).
+	Notification bool
+
+	// Params is the Go type of the method's params, or nil if the
+	// method has no params.
+	Params reflect.Type
+
+	// Result is the Go type of the method's result, or nil if the
+	// method is a notification or its result is always null.
+	Result reflect.Type
+}
+
+var (
+	methodsMu sync.RWMutex
+	methods   = map[string]MethodInfo{}
+)
+
+// RegisterMethod adds a method to the registry consulted by
+// LookupMethod and Methods. This package registers the standard LSP
+// methods whose types it defines, and package lspext registers the
+// Sourcegraph extensions. RegisterMethod panics if the method is
+// already registered.
+func RegisterMethod(info MethodInfo) {
+	methodsMu.Lock()
+	defer methodsMu.Unlock()
+	if _, dup := methods[info.Method]; dup {
+		panic("lsp: RegisterMethod called twice for method " + info.Method)
+	}
+	methods[info.Method] = info
+}
+
+// LookupMethod returns the registered description of a method.
+func LookupMethod(method string) (MethodInfo, bool) {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	info, ok := methods[method]
+	return info, ok
+}
+
+// Methods returns the descriptions of all registered methods, sorted
+// by method name.
+func Methods() []MethodInfo {
+	methodsMu.RLock()
+	defer methodsMu.RUnlock()
+	infos := make([]MethodInfo, 0, len(methods))
+	for _, info := range methods {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Method < infos[j].Method })
+	return infos
+}
@@ -0,0 +1,371 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import "reflect"
+
+// The names of the LSP methods whose types this package defines.
+const (
+	MethodInitialize              = "initialize"
+	MethodInitialized             = "initialized"
+	MethodShutdown                = "shutdown"
+	MethodExit                    = "exit"
+	MethodDidOpen                 = "textDocument/didOpen"
+	MethodDidChange               = "textDocument/didChange"
+	MethodDidClose                = "textDocument/didClose"
+	MethodDidSave                 = "textDocument/didSave"
+	MethodDidChangeConfiguration  = "workspace/didChangeConfiguration"
+	MethodDidChangeWatchedFiles   = "workspace/didChangeWatchedFiles"
+	MethodCompletion              = "textDocument/completion"
+	MethodResolveCompletionItem   = "completionItem/resolve"
+	MethodHover                   = "textDocument/hover"
+	MethodSignatureHelp           = "textDocument/signatureHelp"
+	MethodDefinition              = "textDocument/definition"
+	MethodTypeDefinition          = "textDocument/typeDefinition"
+	MethodImplementation          = "textDocument/implementation"
+	MethodReferences              = "textDocument/references"
+	MethodDocumentHighlight       = "textDocument/documentHighlight"
+	MethodDocumentSymbol          = "textDocument/documentSymbol"
+	MethodWorkspaceSymbol         = "workspace/symbol"
+	MethodCodeAction              = "textDocument/codeAction"
+	MethodResolveCodeAction       = "codeAction/resolve"
+	MethodCodeLens                = "textDocument/codeLens"
+	MethodResolveCodeLens         = "codeLens/resolve"
+	MethodFormatting              = "textDocument/formatting"
+	MethodRangeFormatting         = "textDocument/rangeFormatting"
+	MethodOnTypeFormatting        = "textDocument/onTypeFormatting"
+	MethodRename                  = "textDocument/rename"
+	MethodSemanticTokensFull      = "textDocument/semanticTokens/full"
+	MethodSemanticTokensFullDelta = "textDocument/semanticTokens/full/delta"
+	MethodSemanticTokensRange     = "textDocument/semanticTokens/range"
+	MethodPrepareCallHierarchy    = "textDocument/prepareCallHierarchy"
+	MethodIncomingCalls           = "callHierarchy/incomingCalls"
+	MethodOutgoingCalls           = "callHierarchy/outgoingCalls"
+	MethodPrepareTypeHierarchy    = "textDocument/prepareTypeHierarchy"
+	MethodSupertypes              = "typeHierarchy/supertypes"
+	MethodSubtypes                = "typeHierarchy/subtypes"
+	MethodExecuteCommand          = "workspace/executeCommand"
+	MethodShowMessage             = "window/showMessage"
+	MethodShowMessageRequest      = "window/showMessageRequest"
+	MethodLogMessage              = "window/logMessage"
+	MethodTelemetryEvent          = "telemetry/event"
+	MethodPublishDiagnostics      = "textDocument/publishDiagnostics"
+	MethodSemanticHighlighting    = "textDocument/semanticHighlighting"
+	MethodWorkspaceConfiguration  = "workspace/configuration"
+	MethodCancelRequest           = "$/cancelRequest"
+)
+
+func init() {
+	RegisterMethod(MethodInfo{
+		Method:       MethodInitialize,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*InitializeParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**InitializeResult)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodInitialized,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*InitializedParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodShutdown,
+		Direction:    ClientToServer,
+		Notification: false,
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodExit,
+		Direction:    ClientToServer,
+		Notification: true,
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidOpen,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidOpenTextDocumentParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidChange,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidChangeTextDocumentParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidClose,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidCloseTextDocumentParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidSave,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidSaveTextDocumentParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidChangeConfiguration,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidChangeConfigurationParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDidChangeWatchedFiles,
+		Direction:    ClientToServer,
+		Notification: true,
+		Params:       reflect.TypeOf((*DidChangeWatchedFilesParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodCompletion,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CompletionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**CompletionList)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodResolveCompletionItem,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CompletionItem)(nil)).Elem(),
+		Result:       reflect.TypeOf((**CompletionItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodHover,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**Hover)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSignatureHelp,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**SignatureHelp)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDefinition,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]Location)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodTypeDefinition,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]Location)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodImplementation,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]Location)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodReferences,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*ReferenceParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]Location)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDocumentHighlight,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TextDocumentPositionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]DocumentHighlight)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodDocumentSymbol,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*DocumentSymbolParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]SymbolInformation)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodWorkspaceSymbol,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*WorkspaceSymbolParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]SymbolInformation)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodCodeAction,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CodeActionParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]CommandOrCodeAction)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodResolveCodeAction,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CodeAction)(nil)).Elem(),
+		Result:       reflect.TypeOf((**CodeAction)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodCodeLens,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CodeLensParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]CodeLens)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodResolveCodeLens,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CodeLens)(nil)).Elem(),
+		Result:       reflect.TypeOf((**CodeLens)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodFormatting,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*DocumentFormattingParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TextEdit)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodRangeFormatting,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*DocumentRangeFormattingParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TextEdit)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodOnTypeFormatting,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*DocumentOnTypeFormattingParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TextEdit)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodRename,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*RenameParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**WorkspaceEdit)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSemanticTokensFull,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*SemanticTokensParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**SemanticTokens)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSemanticTokensFullDelta,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*SemanticTokensDeltaParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**SemanticTokensOrDelta)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSemanticTokensRange,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*SemanticTokensRangeParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**SemanticTokens)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodPrepareCallHierarchy,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CallHierarchyPrepareParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]CallHierarchyItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodIncomingCalls,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CallHierarchyIncomingCallsParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]CallHierarchyIncomingCall)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodOutgoingCalls,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*CallHierarchyOutgoingCallsParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]CallHierarchyOutgoingCall)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodPrepareTypeHierarchy,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TypeHierarchyPrepareParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TypeHierarchyItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSupertypes,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TypeHierarchySupertypesParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TypeHierarchyItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSubtypes,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*TypeHierarchySubtypesParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*[]TypeHierarchyItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodExecuteCommand,
+		Direction:    ClientToServer,
+		Notification: false,
+		Params:       reflect.TypeOf((*ExecuteCommandParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*interface{})(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodShowMessage,
+		Direction:    ServerToClient,
+		Notification: true,
+		Params:       reflect.TypeOf((*ShowMessageParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodShowMessageRequest,
+		Direction:    ServerToClient,
+		Notification: false,
+		Params:       reflect.TypeOf((*ShowMessageRequestParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((**MessageActionItem)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodLogMessage,
+		Direction:    ServerToClient,
+		Notification: true,
+		Params:       reflect.TypeOf((*LogMessageParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodTelemetryEvent,
+		Direction:    ServerToClient,
+		Notification: true,
+		Params:       reflect.TypeOf((*interface{})(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodPublishDiagnostics,
+		Direction:    ServerToClient,
+		Notification: true,
+		Params:       reflect.TypeOf((*PublishDiagnosticsParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodSemanticHighlighting,
+		Direction:    ServerToClient,
+		Notification: true,
+		Params:       reflect.TypeOf((*SemanticHighlightingParams)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodWorkspaceConfiguration,
+		Direction:    ServerToClient,
+		Notification: false,
+		Params:       reflect.TypeOf((*ConfigurationParams)(nil)).Elem(),
+		Result:       reflect.TypeOf((*ConfigurationResult)(nil)).Elem(),
+	})
+	RegisterMethod(MethodInfo{
+		Method:       MethodCancelRequest,
+		Direction:    BothDirections,
+		Notification: true,
+		Params:       reflect.TypeOf((*CancelParams)(nil)).Elem(),
+	})
+}
@@ -0,0 +1,79 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLookupMethod(t *testing.T) {
+	info, ok := LookupMethod("textDocument/hover")
+	if !ok {
+		t.Fatal("textDocument/hover is not registered")
+	}
+	want := MethodInfo{
+		Method:    MethodHover,
+		Direction: ClientToServer,
+		Params:    reflect.TypeOf(TextDocumentPositionParams{}),
+		Result:    reflect.TypeOf(&Hover{}),
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("got %+v, want %+v", info, want)
+	}
+
+	if _, ok := LookupMethod("textDocument/unknown"); ok {
+		t.Error("unknown method is registered")
+	}
+}
+
+func TestMethods(t *testing.T) {
+	infos := Methods()
+	if !sort.SliceIsSorted(infos, func(i, j int) bool { return infos[i].Method < infos[j].Method }) {
+		t.Error("methods are not sorted")
+	}
+
+	h := NewServerHandler(UnimplementedLanguageServer{})
+	for _, info := range infos {
+		if info.Notification && info.Result != nil {
+			t.Errorf("%s: notification has a result type", info.Method)
+		}
+		if info.Params != nil {
+			// The zero params must survive a JSON round trip.
+			data, err := json.Marshal(reflect.New(info.Params).Interface())
+			if err != nil {
+				t.Errorf("%s: %s", info.Method, err)
+				continue
+			}
+			if err := json.Unmarshal(data, reflect.New(info.Params).Interface()); err != nil {
+				t.Errorf("%s: %s", info.Method, err)
+			}
+		}
+
+		// Every client-to-server method is dispatched to
+		// LanguageServer, which reports it as unimplemented rather
+		// than failing to decode its params.
+		if info.Direction == ClientToServer {
+			var err error
+			if info.Notification {
+				err = h.HandleNotification(context.Background(), &Notification{Method: info.Method})
+			} else {
+				_, err = h.HandleRequest(context.Background(), &Request{Method: info.Method})
+			}
+			if !errors.Is(err, CodeMethodNotFound) {
+				t.Errorf("%s: got error %v, want code %s", info.Method, err, CodeMethodNotFound)
+			}
+		}
+	}
+}
+
+func TestRegisterMethod_duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	RegisterMethod(MethodInfo{Method: MethodHover})
+}
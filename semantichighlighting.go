@@ -0,0 +1,208 @@
+package lsp
+
+import (
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// ScopeTokenType is the semantic token type and modifiers that a
+// TextMate scope of the textDocument/semanticHighlighting proposal
+// corresponds to.
+type ScopeTokenType struct {
+	Type      SemanticTokenType
+	Modifiers []SemanticTokenModifier
+}
+
+// SemanticHighlightingLines holds the semantic highlighting tokens of
+// a document by line, as sent in textDocument/semanticHighlighting
+// notifications.
+type SemanticHighlightingLines map[int]SemanticHighlightingTokens
+
+// Update updates l with the lines of a textDocument/semanticHighlighting
+// notification. A line without tokens is removed.
+func (l SemanticHighlightingLines) Update(lines []SemanticHighlightingInformation) {
+	for _, info := range lines {
+		if len(info.Tokens) == 0 {
+			delete(l, info.Line)
+		} else {
+			l[info.Line] = info.Tokens
+		}
+	}
+}
+
+// Information returns the lines of a textDocument/semanticHighlighting
+// notification that updates a client that has the lines prev to l:
+// the lines of l that differ from those of prev, and the lines of
+// prev that are not in l, without tokens. The lines are sorted.
+func (l SemanticHighlightingLines) Information(prev SemanticHighlightingLines) []SemanticHighlightingInformation {
+	var lines []SemanticHighlightingInformation
+	for line, tokens := range l {
+		if !semanticHighlightingTokensEqual(tokens, prev[line]) {
+			lines = append(lines, SemanticHighlightingInformation{Line: line, Tokens: tokens})
+		}
+	}
+	for line := range prev {
+		if _, ok := l[line]; !ok {
+			lines = append(lines, SemanticHighlightingInformation{Line: line})
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Line < lines[j].Line })
+	return lines
+}
+
+func semanticHighlightingTokensEqual(a, b SemanticHighlightingTokens) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SemanticHighlightingConverter converts between the semantic
+// highlighting tokens of the textDocument/semanticHighlighting
+// proposal, whose Scope is an index in the scopes of
+// SemanticHighlightingOptions, and the standard semantic tokens of a
+// legend. It lets a proxy bridge clients and servers that support only
+// one of them.
+//
+// A SemanticHighlightingConverter is safe for concurrent use.
+type SemanticHighlightingConverter struct {
+	legend SemanticTokensLegend
+
+	// tokenTypes are the semantic token types and modifiers, encoded
+	// for legend, of the scope indexes.
+	tokenTypes []encodedScopeTokenType
+}
+
+type encodedScopeTokenType struct {
+	ok             bool // whether the scope maps to a type in the legend
+	typ, modifiers uint32
+}
+
+// NewSemanticHighlightingConverter returns a converter between the
+// scope indexes of the lookup table scopes (as in
+// (SemanticHighlightingOptions).Scopes) and the token types and
+// modifiers of legend.
+//
+// The semantic token type of a scope index is given by mapping, for the
+// first of its TextMate scopes that mapping contains. As in TextMate, a
+// scope also matches its parent scopes: "entity.name.function.go"
+// matches "entity.name.function", then "entity.name" and "entity". The
+// scope indexes with no type in legend, and the modifiers not in
+// legend, are ignored.
+func NewSemanticHighlightingConverter(scopes [][]string, mapping map[string]ScopeTokenType, legend SemanticTokensLegend) *SemanticHighlightingConverter {
+	c := &SemanticHighlightingConverter{
+		legend:     legend,
+		tokenTypes: make([]encodedScopeTokenType, len(scopes)),
+	}
+	b := NewSemanticTokensBuilder(legend)
+	for i, s := range scopes {
+		tt, ok := lookupScope(s, mapping)
+		if !ok {
+			continue
+		}
+		typ, modifiers, ok := b.encode(tt.Type, tt.Modifiers)
+		if !ok {
+			continue
+		}
+		c.tokenTypes[i] = encodedScopeTokenType{ok: true, typ: typ, modifiers: modifiers}
+	}
+	return c
+}
+
+// lookupScope returns the entry of mapping for the first of scopes, or
+// of its parent scopes, that mapping contains.
+func lookupScope(scopes []string, mapping map[string]ScopeTokenType) (ScopeTokenType, bool) {
+	for _, s := range scopes {
+		for {
+			if tt, ok := mapping[s]; ok {
+				return tt, true
+			}
+			i := strings.LastIndexByte(s, '.')
+			if i < 0 {
+				break
+			}
+			s = s[:i]
+		}
+	}
+	return ScopeTokenType{}, false
+}
+
+// ToSemanticTokens returns the Data of the SemanticTokens of a document
+// with the given semantic highlighting lines. The tokens whose scope
+// index has no token type are omitted.
+func (c *SemanticHighlightingConverter) ToSemanticTokens(lines SemanticHighlightingLines) []uint32 {
+	var tokens []encodedSemanticToken
+	for line, lineTokens := range lines {
+		for _, t := range lineTokens {
+			if int(t.Scope) >= len(c.tokenTypes) || !c.tokenTypes[t.Scope].ok {
+				continue
+			}
+			tt := c.tokenTypes[t.Scope]
+			tokens = append(tokens, encodedSemanticToken{
+				line:      uint32(line),
+				char:      t.Character,
+				length:    uint32(t.Length),
+				typ:       tt.typ,
+				modifiers: tt.modifiers,
+			})
+		}
+	}
+	b := SemanticTokensBuilder{tokens: tokens}
+	return b.Build()
+}
+
+// FromSemanticTokens returns the semantic highlighting lines of the
+// tokens encoded in data (the Data of a SemanticTokens). Each token is
+// given the scope index with its token type and modifiers or, if there
+// is none, with its token type and as many of its modifiers as
+// possible. The tokens with no such scope index, or that are too long
+// for a SemanticHighlightingToken, are omitted.
+func (c *SemanticHighlightingConverter) FromSemanticTokens(data []uint32) (SemanticHighlightingLines, error) {
+	tokens, err := DecodeSemanticTokens(c.legend, data)
+	if err != nil {
+		return nil, err
+	}
+	lines := SemanticHighlightingLines{}
+	for i, t := range tokens {
+		if t.Length > 0xffff {
+			continue
+		}
+		scope, ok := c.scope(data[5*i+3], data[5*i+4])
+		if !ok {
+			continue
+		}
+		lines[t.Line] = append(lines[t.Line], SemanticHighlightingToken{
+			Character: uint32(t.Character),
+			Length:    uint16(t.Length),
+			Scope:     scope,
+		})
+	}
+	return lines, nil
+}
+
+// scope returns the scope index that best matches an encoded token
+// type and modifiers.
+func (c *SemanticHighlightingConverter) scope(typ, modifiers uint32) (uint16, bool) {
+	best, bestCount := -1, -1
+	for i, tt := range c.tokenTypes {
+		if i > 0xffff {
+			break
+		}
+		if !tt.ok || tt.typ != typ || tt.modifiers&^modifiers != 0 {
+			continue
+		}
+		if tt.modifiers == modifiers {
+			return uint16(i), true
+		}
+		if n := bits.OnesCount32(tt.modifiers); n > bestCount {
+			best, bestCount = i, n
+		}
+	}
+	return uint16(best), best >= 0
+}
@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSemanticHighlightingConverter(t *testing.T) {
+	scopes := [][]string{
+		{"keyword.control.go"},
+		{"entity.name.function.go"},
+		{"variable.other.readwrite.go"},
+		{"variable.other.constant.go"},
+		{"comment.line.double-slash.go"},
+		{"storage.type.go", "entity.name.type"},
+	}
+	mapping := map[string]ScopeTokenType{
+		"keyword":                 {Type: STTKeyword},
+		"entity.name.function":    {Type: STTFunction},
+		"variable":                {Type: STTVariable},
+		"variable.other.constant": {Type: STTVariable, Modifiers: []SemanticTokenModifier{STMReadonly}},
+		"entity.name.type":        {Type: STTType},
+	}
+	c := NewSemanticHighlightingConverter(scopes, mapping, testLegend)
+
+	lines := SemanticHighlightingLines{
+		0: {{Character: 0, Length: 4, Scope: 0}, {Character: 5, Length: 1, Scope: 1}},
+		2: {{Character: 8, Length: 2, Scope: 3}, {Character: 1, Length: 3, Scope: 2}},
+		3: {{Character: 0, Length: 9, Scope: 4}}, // no token type
+		4: {{Character: 0, Length: 3, Scope: 5}}, // type not in the legend
+		5: {{Character: 0, Length: 3, Scope: 42}},
+	}
+	data := c.ToSemanticTokens(lines)
+	want := []uint32{
+		0, 0, 4, 0, 0,
+		0, 5, 1, 1, 0,
+		2, 1, 3, 2, 0,
+		0, 7, 2, 2, 2,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("got %v, want %v", data, want)
+	}
+
+	got, err := c.FromSemanticTokens(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLines := SemanticHighlightingLines{
+		0: {{Character: 0, Length: 4, Scope: 0}, {Character: 5, Length: 1, Scope: 1}},
+		2: {{Character: 1, Length: 3, Scope: 2}, {Character: 8, Length: 2, Scope: 3}},
+	}
+	if !reflect.DeepEqual(got, wantLines) {
+		t.Errorf("got %v, want %v", got, wantLines)
+	}
+
+	// A token with modifiers that no scope has gets the scope of its
+	// type with the most of its modifiers.
+	got, err = c.FromSemanticTokens([]uint32{1, 2, 3, 2, 3, 0, 4, 1, 1, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLines = SemanticHighlightingLines{
+		1: {{Character: 2, Length: 3, Scope: 3}, {Character: 6, Length: 1, Scope: 1}},
+	}
+	if !reflect.DeepEqual(got, wantLines) {
+		t.Errorf("got %v, want %v", got, wantLines)
+	}
+
+	if got, err := c.FromSemanticTokens([]uint32{0, 0, 1, 7, 0}); err == nil {
+		t.Errorf("got %v, want error", got)
+	}
+}
+
+func TestSemanticHighlightingLines(t *testing.T) {
+	prev := SemanticHighlightingLines{}
+	prev.Update([]SemanticHighlightingInformation{
+		{Line: 0, Tokens: SemanticHighlightingTokens{{Character: 0, Length: 1, Scope: 0}}},
+		{Line: 1, Tokens: SemanticHighlightingTokens{{Character: 0, Length: 2, Scope: 0}}},
+		{Line: 2, Tokens: SemanticHighlightingTokens{{Character: 0, Length: 3, Scope: 0}}},
+	})
+
+	l := SemanticHighlightingLines{}
+	for line, tokens := range prev {
+		l[line] = tokens
+	}
+	l.Update([]SemanticHighlightingInformation{
+		{Line: 0},
+		{Line: 2, Tokens: SemanticHighlightingTokens{{Character: 1, Length: 3, Scope: 1}}},
+		{Line: 3, Tokens: SemanticHighlightingTokens{{Character: 0, Length: 1, Scope: 1}}},
+	})
+
+	got := l.Information(prev)
+	want := []SemanticHighlightingInformation{
+		{Line: 0},
+		{Line: 2, Tokens: SemanticHighlightingTokens{{Character: 1, Length: 3, Scope: 1}}},
+		{Line: 3, Tokens: SemanticHighlightingTokens{{Character: 0, Length: 1, Scope: 1}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := l.Information(l); got != nil {
+		t.Errorf("got %+v for unchanged lines, want none", got)
+	}
+}
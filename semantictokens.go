@@ -0,0 +1,464 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SemanticTokenType is the type of a semantic token. Servers and
+// clients may use types other than the predefined ones.
+type SemanticTokenType string
+
+const (
+	STTNamespace     SemanticTokenType = "namespace"
+	STTType          SemanticTokenType = "type"
+	STTClass         SemanticTokenType = "class"
+	STTEnum          SemanticTokenType = "enum"
+	STTInterface     SemanticTokenType = "interface"
+	STTStruct        SemanticTokenType = "struct"
+	STTTypeParameter SemanticTokenType = "typeParameter"
+	STTParameter     SemanticTokenType = "parameter"
+	STTVariable      SemanticTokenType = "variable"
+	STTProperty      SemanticTokenType = "property"
+	STTEnumMember    SemanticTokenType = "enumMember"
+	STTEvent         SemanticTokenType = "event"
+	STTFunction      SemanticTokenType = "function"
+	STTMethod        SemanticTokenType = "method"
+	STTMacro         SemanticTokenType = "macro"
+	STTKeyword       SemanticTokenType = "keyword"
+	STTModifier      SemanticTokenType = "modifier"
+	STTComment       SemanticTokenType = "comment"
+	STTString        SemanticTokenType = "string"
+	STTNumber        SemanticTokenType = "number"
+	STTRegexp        SemanticTokenType = "regexp"
+	STTOperator      SemanticTokenType = "operator"
+	STTDecorator     SemanticTokenType = "decorator"
+)
+
+// SemanticTokenModifier is a modifier of a semantic token. Servers and
+// clients may use modifiers other than the predefined ones.
+type SemanticTokenModifier string
+
+const (
+	STMDeclaration    SemanticTokenModifier = "declaration"
+	STMDefinition     SemanticTokenModifier = "definition"
+	STMReadonly       SemanticTokenModifier = "readonly"
+	STMStatic         SemanticTokenModifier = "static"
+	STMDeprecated     SemanticTokenModifier = "deprecated"
+	STMAbstract       SemanticTokenModifier = "abstract"
+	STMAsync          SemanticTokenModifier = "async"
+	STMModification   SemanticTokenModifier = "modification"
+	STMDocumentation  SemanticTokenModifier = "documentation"
+	STMDefaultLibrary SemanticTokenModifier = "defaultLibrary"
+)
+
+// TokenFormat is the format of the data of SemanticTokens.
+type TokenFormat string
+
+const TFRelative TokenFormat = "relative"
+
+// SemanticTokensLegend lists the token types and modifiers used by a
+// server. In the encoded tokens, a type is an index in TokenTypes, and
+// the modifiers are a bit set in which bit i stands for
+// TokenModifiers[i].
+type SemanticTokensLegend struct {
+	TokenTypes     []SemanticTokenType     `json:"tokenTypes"`
+	TokenModifiers []SemanticTokenModifier `json:"tokenModifiers"`
+}
+
+// NegotiateSemanticTokensLegend returns the legend that a server
+// supporting the token types and modifiers of supported should use
+// with a client that advertises the given tokenTypes and
+// tokenModifiers capabilities: the types and modifiers of supported
+// that the client also supports, in the same order.
+func NegotiateSemanticTokensLegend(clientTypes []SemanticTokenType, clientModifiers []SemanticTokenModifier, supported SemanticTokensLegend) SemanticTokensLegend {
+	types := make(map[SemanticTokenType]bool, len(clientTypes))
+	for _, t := range clientTypes {
+		types[t] = true
+	}
+	modifiers := make(map[SemanticTokenModifier]bool, len(clientModifiers))
+	for _, m := range clientModifiers {
+		modifiers[m] = true
+	}
+	legend := SemanticTokensLegend{
+		TokenTypes:     []SemanticTokenType{},
+		TokenModifiers: []SemanticTokenModifier{},
+	}
+	for _, t := range supported.TokenTypes {
+		if types[t] {
+			legend.TokenTypes = append(legend.TokenTypes, t)
+		}
+	}
+	for _, m := range supported.TokenModifiers {
+		if modifiers[m] {
+			legend.TokenModifiers = append(legend.TokenModifiers, m)
+		}
+	}
+	return legend
+}
+
+// SemanticTokensRangeSupport reports whether textDocument/semanticTokens/range
+// requests are supported. It is encoded as a bool, and decoded from
+// either a bool or an (empty) object, which means true.
+type SemanticTokensRangeSupport bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *SemanticTokensRangeSupport) UnmarshalJSON(data []byte) error {
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*v = SemanticTokensRangeSupport(b)
+		return nil
+	}
+	var tmp struct{}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*v = true
+	return nil
+}
+
+type SemanticTokensFullOptions struct {
+	// Delta reports whether textDocument/semanticTokens/full/delta
+	// requests are supported.
+	Delta bool `json:"delta,omitempty"`
+}
+
+// SemanticTokensFullOptionsOrBool holds either a bool or
+// SemanticTokensFullOptions. The LSP API allows either to be specified
+// for the support of textDocument/semanticTokens/full requests.
+type SemanticTokensFullOptionsOrBool struct {
+	Bool    bool
+	Options *SemanticTokensFullOptions
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *SemanticTokensFullOptionsOrBool) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	if v.Options != nil {
+		return json.Marshal(v.Options)
+	}
+	return json.Marshal(v.Bool)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. If
+// SemanticTokensFullOptions are given, Bool is set to true.
+func (v *SemanticTokensFullOptionsOrBool) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = SemanticTokensFullOptionsOrBool{}
+		return nil
+	}
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*v = SemanticTokensFullOptionsOrBool{Bool: b}
+		return nil
+	}
+	var tmp SemanticTokensFullOptions
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*v = SemanticTokensFullOptionsOrBool{Bool: true, Options: &tmp}
+	return nil
+}
+
+// SemanticTokensOptions is the (ServerCapabilities).SemanticTokensProvider
+// capability.
+type SemanticTokensOptions struct {
+	Legend SemanticTokensLegend             `json:"legend"`
+	Range  SemanticTokensRangeSupport       `json:"range,omitempty"`
+	Full   *SemanticTokensFullOptionsOrBool `json:"full,omitempty"`
+}
+
+type SemanticTokensParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+type SemanticTokensDeltaParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+
+	// PreviousResultID is the ResultID of the SemanticTokens or
+	// SemanticTokensDelta that the returned delta applies to.
+	PreviousResultID string `json:"previousResultId"`
+}
+
+type SemanticTokensRangeParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+}
+
+type SemanticTokens struct {
+	// ResultID, if set, identifies these tokens in a later
+	// textDocument/semanticTokens/full/delta request.
+	ResultID string `json:"resultId,omitempty"`
+
+	// Data holds the tokens, encoded as described in
+	// EncodeSemanticTokens.
+	Data []uint32 `json:"data"`
+}
+
+type SemanticTokensDelta struct {
+	ResultID string `json:"resultId,omitempty"`
+
+	// Edits transform the Data of the previous result into that of the
+	// new one.
+	Edits []SemanticTokensEdit `json:"edits"`
+}
+
+// SemanticTokensEdit replaces the DeleteCount elements at index Start
+// of the Data of a SemanticTokens with Data.
+type SemanticTokensEdit struct {
+	Start       int      `json:"start"`
+	DeleteCount int      `json:"deleteCount"`
+	Data        []uint32 `json:"data,omitempty"`
+}
+
+// SemanticTokensOrDelta holds the result of a
+// textDocument/semanticTokens/full/delta request, which is either
+// SemanticTokens or a SemanticTokensDelta. Exactly one field must be
+// set.
+type SemanticTokensOrDelta struct {
+	Tokens *SemanticTokens
+	Delta  *SemanticTokensDelta
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *SemanticTokensOrDelta) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	switch {
+	case v.Tokens != nil && v.Delta == nil:
+		return json.Marshal(v.Tokens)
+	case v.Delta != nil && v.Tokens == nil:
+		return json.Marshal(v.Delta)
+	}
+	return nil, errors.New("lsp: SemanticTokensOrDelta must have exactly one field set")
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A value is a
+// SemanticTokensDelta if it has an "edits" field.
+func (v *SemanticTokensOrDelta) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = SemanticTokensOrDelta{}
+		return nil
+	}
+	var peek struct {
+		Edits json.RawMessage `json:"edits"`
+	}
+	if err := json.Unmarshal(data, &peek); err != nil {
+		return err
+	}
+	if peek.Edits != nil {
+		var tmp SemanticTokensDelta
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return err
+		}
+		*v = SemanticTokensOrDelta{Delta: &tmp}
+		return nil
+	}
+	var tmp SemanticTokens
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*v = SemanticTokensOrDelta{Tokens: &tmp}
+	return nil
+}
+
+// SemanticToken is a semantic token at an absolute position, with its
+// Character and Length expressed in the position encoding in use.
+type SemanticToken struct {
+	Line      int
+	Character int
+	Length    int
+	Type      SemanticTokenType
+	Modifiers []SemanticTokenModifier
+}
+
+// EncodeSemanticTokens returns the encoding of tokens in the Data of
+// SemanticTokens, with the types and modifiers of the tokens mapped to
+// their indexes in legend.
+//
+// Each token is encoded as 5 integers: its line relative to the line
+// of the previous token, its start character (relative to that of the
+// previous token, if on the same line), its length, its type and its
+// modifiers. The tokens are sorted by position; those whose type is
+// not in legend are omitted, as are the modifiers that are not in
+// legend.
+func EncodeSemanticTokens(legend SemanticTokensLegend, tokens []SemanticToken) []uint32 {
+	b := NewSemanticTokensBuilder(legend)
+	for _, t := range tokens {
+		b.Add(t.Line, t.Character, t.Length, t.Type, t.Modifiers...)
+	}
+	return b.Build()
+}
+
+// DecodeSemanticTokens returns the tokens encoded in data, as described
+// in EncodeSemanticTokens. It is an error for data not to consist of
+// whole tokens, or for a type or modifier not to be in legend.
+func DecodeSemanticTokens(legend SemanticTokensLegend, data []uint32) ([]SemanticToken, error) {
+	if len(data)%5 != 0 {
+		return nil, fmt.Errorf("lsp: semantic tokens data has length %d, which is not a multiple of 5", len(data))
+	}
+	tokens := make([]SemanticToken, 0, len(data)/5)
+	line, char := 0, 0
+	for i := 0; i < len(data); i += 5 {
+		if data[i] > 0 {
+			line += int(data[i])
+			char = 0
+		}
+		char += int(data[i+1])
+		t := SemanticToken{Line: line, Character: char, Length: int(data[i+2])}
+		if typ := data[i+3]; int(typ) < len(legend.TokenTypes) {
+			t.Type = legend.TokenTypes[typ]
+		} else {
+			return nil, fmt.Errorf("lsp: semantic token %d has type %d, which is not in the legend", i/5, typ)
+		}
+		for mods, bit := data[i+4], 0; mods != 0; mods, bit = mods>>1, bit+1 {
+			if mods&1 == 0 {
+				continue
+			}
+			if bit >= len(legend.TokenModifiers) {
+				return nil, fmt.Errorf("lsp: semantic token %d has modifier %d, which is not in the legend", i/5, bit)
+			}
+			t.Modifiers = append(t.Modifiers, legend.TokenModifiers[bit])
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// SemanticTokensBuilder builds the encoding of semantic tokens, which
+// may be added in any order. See EncodeSemanticTokens.
+type SemanticTokensBuilder struct {
+	types     map[SemanticTokenType]uint32
+	modifiers map[SemanticTokenModifier]uint32
+	tokens    []encodedSemanticToken
+}
+
+// encodedSemanticToken is a token at an absolute position, with its
+// type and modifiers encoded.
+type encodedSemanticToken struct {
+	line, char, length uint32
+	typ, modifiers     uint32
+}
+
+// NewSemanticTokensBuilder returns a builder of the tokens of the
+// types and modifiers of legend.
+func NewSemanticTokensBuilder(legend SemanticTokensLegend) *SemanticTokensBuilder {
+	b := &SemanticTokensBuilder{
+		types:     make(map[SemanticTokenType]uint32, len(legend.TokenTypes)),
+		modifiers: make(map[SemanticTokenModifier]uint32, len(legend.TokenModifiers)),
+	}
+	for i, t := range legend.TokenTypes {
+		if _, ok := b.types[t]; !ok {
+			b.types[t] = uint32(i)
+		}
+	}
+	for i, m := range legend.TokenModifiers {
+		if _, ok := b.modifiers[m]; !ok && i < 32 {
+			b.modifiers[m] = 1 << uint(i)
+		}
+	}
+	return b
+}
+
+// Add adds a token. It is ignored if typ is not in the legend, and so
+// are the modifiers that are not in the legend.
+func (b *SemanticTokensBuilder) Add(line, char, length int, typ SemanticTokenType, modifiers ...SemanticTokenModifier) {
+	t, mods, ok := b.encode(typ, modifiers)
+	if !ok {
+		return
+	}
+	b.tokens = append(b.tokens, encodedSemanticToken{
+		line:      uint32(line),
+		char:      uint32(char),
+		length:    uint32(length),
+		typ:       t,
+		modifiers: mods,
+	})
+}
+
+// encode returns the index of typ in the legend and the bit set of
+// the modifiers in the legend, or false if typ is not in the legend.
+func (b *SemanticTokensBuilder) encode(typ SemanticTokenType, modifiers []SemanticTokenModifier) (t, mods uint32, ok bool) {
+	t, ok = b.types[typ]
+	if !ok {
+		return 0, 0, false
+	}
+	for _, m := range modifiers {
+		mods |= b.modifiers[m]
+	}
+	return t, mods, true
+}
+
+// Build returns the encoding of the tokens added so far.
+func (b *SemanticTokensBuilder) Build() []uint32 {
+	sort.SliceStable(b.tokens, func(i, j int) bool {
+		if b.tokens[i].line != b.tokens[j].line {
+			return b.tokens[i].line < b.tokens[j].line
+		}
+		return b.tokens[i].char < b.tokens[j].char
+	})
+	data := make([]uint32, 0, 5*len(b.tokens))
+	var line, char uint32
+	for _, t := range b.tokens {
+		if t.line != line {
+			char = 0
+		}
+		data = append(data, t.line-line, t.char-char, t.length, t.typ, t.modifiers)
+		line, char = t.line, t.char
+	}
+	return data
+}
+
+// ComputeSemanticTokensEdits returns the edits that transform the
+// encoded tokens oldData into newData, for a SemanticTokensDelta. The
+// edits replace whole tokens, and there is at most one: the tokens
+// between those at the start and at the end that are the same in both.
+func ComputeSemanticTokensEdits(oldData, newData []uint32) []SemanticTokensEdit {
+	prefix := 0
+	for prefix < len(oldData) && prefix < len(newData) && oldData[prefix] == newData[prefix] {
+		prefix++
+	}
+	if prefix == len(oldData) && prefix == len(newData) {
+		return nil
+	}
+	prefix -= prefix % 5
+
+	suffix := 0
+	for suffix < len(oldData)-prefix && suffix < len(newData)-prefix && oldData[len(oldData)-1-suffix] == newData[len(newData)-1-suffix] {
+		suffix++
+	}
+	suffix -= suffix % 5
+
+	edit := SemanticTokensEdit{Start: prefix, DeleteCount: len(oldData) - prefix - suffix}
+	if n := len(newData) - prefix - suffix; n > 0 {
+		edit.Data = append([]uint32(nil), newData[prefix:prefix+n]...)
+	}
+	return []SemanticTokensEdit{edit}
+}
+
+// ApplySemanticTokensEdits returns the result of applying the edits of
+// a SemanticTokensDelta to the encoded tokens data. As for TextEdits,
+// the edits refer to the original data and must not overlap.
+func ApplySemanticTokensEdits(data []uint32, edits []SemanticTokensEdit) ([]uint32, error) {
+	sorted := make([]SemanticTokensEdit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	result := make([]uint32, 0, len(data))
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.DeleteCount < 0 || e.Start+e.DeleteCount > len(data) {
+			return nil, fmt.Errorf("lsp: invalid semantic tokens edit (start %d, deleteCount %d) of data of length %d", e.Start, e.DeleteCount, len(data))
+		}
+		result = append(result, data[last:e.Start]...)
+		result = append(result, e.Data...)
+		last = e.Start + e.DeleteCount
+	}
+	return append(result, data[last:]...), nil
+}
@@ -0,0 +1,255 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var testLegend = SemanticTokensLegend{
+	TokenTypes:     []SemanticTokenType{STTKeyword, STTFunction, STTVariable},
+	TokenModifiers: []SemanticTokenModifier{STMDeclaration, STMReadonly},
+}
+
+func TestEncodeSemanticTokens(t *testing.T) {
+	tokens := []SemanticToken{
+		{Line: 2, Character: 5, Length: 3, Type: STTFunction, Modifiers: []SemanticTokenModifier{STMDeclaration}},
+		{Line: 0, Character: 0, Length: 7, Type: STTKeyword},
+		{Line: 2, Character: 10, Length: 1, Type: STTVariable, Modifiers: []SemanticTokenModifier{STMReadonly, STMDeclaration}},
+		{Line: 1, Character: 0, Length: 2, Type: STTComment}, // not in the legend
+		{Line: 3, Character: 1, Length: 2, Type: STTVariable, Modifiers: []SemanticTokenModifier{STMStatic}},
+	}
+	data := EncodeSemanticTokens(testLegend, tokens)
+	want := []uint32{
+		0, 0, 7, 0, 0,
+		2, 5, 3, 1, 1,
+		0, 5, 1, 2, 3,
+		1, 1, 2, 2, 0,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("got %v, want %v", data, want)
+	}
+
+	got, err := DecodeSemanticTokens(testLegend, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTokens := []SemanticToken{
+		{Line: 0, Character: 0, Length: 7, Type: STTKeyword},
+		{Line: 2, Character: 5, Length: 3, Type: STTFunction, Modifiers: []SemanticTokenModifier{STMDeclaration}},
+		{Line: 2, Character: 10, Length: 1, Type: STTVariable, Modifiers: []SemanticTokenModifier{STMDeclaration, STMReadonly}},
+		{Line: 3, Character: 1, Length: 2, Type: STTVariable},
+	}
+	if !reflect.DeepEqual(got, wantTokens) {
+		t.Errorf("got %+v, want %+v", got, wantTokens)
+	}
+
+	for _, data := range [][]uint32{
+		{0, 0, 1, 0},
+		{0, 0, 1, 3, 0},
+		{0, 0, 1, 0, 4},
+	} {
+		if got, err := DecodeSemanticTokens(testLegend, data); err == nil {
+			t.Errorf("%v: got %+v, want error", data, got)
+		}
+	}
+}
+
+func TestNegotiateSemanticTokensLegend(t *testing.T) {
+	got := NegotiateSemanticTokensLegend(
+		[]SemanticTokenType{STTVariable, STTKeyword, STTClass},
+		[]SemanticTokenModifier{STMReadonly},
+		testLegend,
+	)
+	want := SemanticTokensLegend{
+		TokenTypes:     []SemanticTokenType{STTKeyword, STTVariable},
+		TokenModifiers: []SemanticTokenModifier{STMReadonly},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSemanticTokensOptions_MarshalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want SemanticTokensOptions
+	}{
+		{
+			data: []byte(`{"legend":{"tokenTypes":["keyword"],"tokenModifiers":[]},"range":true,"full":true}`),
+			want: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{TokenTypes: []SemanticTokenType{STTKeyword}, TokenModifiers: []SemanticTokenModifier{}},
+				Range:  true,
+				Full:   &SemanticTokensFullOptionsOrBool{Bool: true},
+			},
+		},
+		{
+			data: []byte(`{"legend":{"tokenTypes":[],"tokenModifiers":[]},"full":{"delta":true}}`),
+			want: SemanticTokensOptions{
+				Legend: SemanticTokensLegend{TokenTypes: []SemanticTokenType{}, TokenModifiers: []SemanticTokenModifier{}},
+				Full:   &SemanticTokensFullOptionsOrBool{Bool: true, Options: &SemanticTokensFullOptions{Delta: true}},
+			},
+		},
+	}
+	for _, test := range tests {
+		var got SemanticTokensOptions
+		if err := json.Unmarshal(test.data, &got); err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got %+v, want %+v", got, test.want)
+			continue
+		}
+		data, err := json.Marshal(&got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("got JSON %q, want %q", data, test.data)
+		}
+	}
+
+	var opts SemanticTokensOptions
+	if err := json.Unmarshal([]byte(`{"range":{}}`), &opts); err != nil {
+		t.Fatal(err)
+	}
+	if !opts.Range {
+		t.Error("got range false for {}, want true")
+	}
+}
+
+func TestSemanticTokensOrDelta_MarshalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want SemanticTokensOrDelta
+	}{
+		{
+			data: []byte(`{"resultId":"1","data":[0,1,2,0,0]}`),
+			want: SemanticTokensOrDelta{Tokens: &SemanticTokens{ResultID: "1", Data: []uint32{0, 1, 2, 0, 0}}},
+		},
+		{
+			data: []byte(`{"resultId":"2","edits":[{"start":5,"deleteCount":5}]}`),
+			want: SemanticTokensOrDelta{Delta: &SemanticTokensDelta{ResultID: "2", Edits: []SemanticTokensEdit{{Start: 5, DeleteCount: 5}}}},
+		},
+	}
+	for _, test := range tests {
+		var got SemanticTokensOrDelta
+		if err := json.Unmarshal(test.data, &got); err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got %+v, want %+v", got, test.want)
+			continue
+		}
+		data, err := json.Marshal(&got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("got JSON %q, want %q", data, test.data)
+		}
+	}
+
+	if data, err := json.Marshal(&SemanticTokensOrDelta{}); err == nil {
+		t.Errorf("got JSON %q, want error", data)
+	}
+}
+
+func TestComputeSemanticTokensEdits(t *testing.T) {
+	tests := []struct {
+		old, new []uint32
+		want     []SemanticTokensEdit
+	}{
+		{old: []uint32{0, 0, 1, 0, 0}, new: []uint32{0, 0, 1, 0, 0}, want: nil},
+		{old: nil, new: []uint32{0, 0, 1, 0, 0}, want: []SemanticTokensEdit{{Start: 0, DeleteCount: 0, Data: []uint32{0, 0, 1, 0, 0}}}},
+		{old: []uint32{0, 0, 1, 0, 0}, new: nil, want: []SemanticTokensEdit{{Start: 0, DeleteCount: 5}}},
+		{
+			// Only the length of the second token changes, but the
+			// whole token is replaced.
+			old:  []uint32{0, 0, 1, 0, 0, 1, 0, 2, 1, 0, 1, 0, 3, 2, 0},
+			new:  []uint32{0, 0, 1, 0, 0, 1, 0, 4, 1, 0, 1, 0, 3, 2, 0},
+			want: []SemanticTokensEdit{{Start: 5, DeleteCount: 5, Data: []uint32{1, 0, 4, 1, 0}}},
+		},
+		{
+			old:  []uint32{0, 0, 1, 0, 0, 0, 2, 1, 0, 0},
+			new:  []uint32{0, 0, 1, 0, 0, 0, 2, 1, 0, 0, 0, 2, 1, 0, 0},
+			want: []SemanticTokensEdit{{Start: 10, DeleteCount: 0, Data: []uint32{0, 2, 1, 0, 0}}},
+		},
+	}
+	for _, test := range tests {
+		got := ComputeSemanticTokensEdits(test.old, test.new)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v -> %v: got %+v, want %+v", test.old, test.new, got, test.want)
+		}
+	}
+}
+
+func TestComputeSemanticTokensEdits_random(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomData := func() []uint32 {
+		data := make([]uint32, 5*rnd.Intn(6))
+		for i := range data {
+			data[i] = uint32(rnd.Intn(3))
+		}
+		return data
+	}
+	for i := 0; i < 1000; i++ {
+		old, new := randomData(), randomData()
+		edits := ComputeSemanticTokensEdits(old, new)
+		for _, e := range edits {
+			if e.Start%5 != 0 || e.DeleteCount%5 != 0 {
+				t.Fatalf("%v -> %v: edit %+v does not replace whole tokens", old, new, e)
+			}
+		}
+		got, err := ApplySemanticTokensEdits(old, edits)
+		if err != nil {
+			t.Fatalf("%v -> %v: %s", old, new, err)
+		}
+		if len(got) != len(new) || len(new) > 0 && !reflect.DeepEqual(got, new) {
+			t.Fatalf("%v -> %v: applying %+v gives %v", old, new, edits, got)
+		}
+	}
+}
+
+func TestApplySemanticTokensEdits(t *testing.T) {
+	data := []uint32{0, 0, 1, 0, 0, 1, 0, 1, 0, 0}
+	got, err := ApplySemanticTokensEdits(data, []SemanticTokensEdit{
+		{Start: 10, Data: []uint32{0, 2, 1, 0, 0}},
+		{Start: 0, DeleteCount: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint32{1, 0, 1, 0, 0, 0, 2, 1, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, edits := range [][]SemanticTokensEdit{
+		{{Start: 5, DeleteCount: 10}},
+		{{Start: 0, DeleteCount: 5}, {Start: 3, DeleteCount: 1}},
+		{{Start: 0, DeleteCount: -1}},
+	} {
+		if got, err := ApplySemanticTokensEdits(data, edits); err == nil {
+			t.Errorf("%+v: got %v, want error", edits, got)
+		}
+	}
+}
+
+func BenchmarkSemanticTokensBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tb := NewSemanticTokensBuilder(testLegend)
+		for line := 0; line < 1000; line++ {
+			for char := 0; char < 80; char += 8 {
+				tb.Add(line, char, 5, STTVariable, STMReadonly)
+			}
+		}
+		tb.Build()
+	}
+}
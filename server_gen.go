@@ -0,0 +1,532 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// LanguageServer is implemented by language servers, with one method
+// per request or notification sent from the client to the server. Use
+// NewServerHandler to serve it.
+//
+// Implementations should embed UnimplementedLanguageServer so that
+// they only need to implement the methods they support (and so that
+// they keep compiling when methods are added to this interface).
+type LanguageServer interface {
+	// Initialize handles the request "initialize".
+	Initialize(ctx context.Context, params *InitializeParams) (*InitializeResult, error)
+
+	// Initialized handles the notification "initialized".
+	Initialized(ctx context.Context, params *InitializedParams) error
+
+	// Shutdown handles the request "shutdown".
+	Shutdown(ctx context.Context) error
+
+	// Exit handles the notification "exit".
+	Exit(ctx context.Context) error
+
+	// DidOpen handles the notification "textDocument/didOpen".
+	DidOpen(ctx context.Context, params *DidOpenTextDocumentParams) error
+
+	// DidChange handles the notification "textDocument/didChange".
+	DidChange(ctx context.Context, params *DidChangeTextDocumentParams) error
+
+	// DidClose handles the notification "textDocument/didClose".
+	DidClose(ctx context.Context, params *DidCloseTextDocumentParams) error
+
+	// DidSave handles the notification "textDocument/didSave".
+	DidSave(ctx context.Context, params *DidSaveTextDocumentParams) error
+
+	// DidChangeConfiguration handles the notification "workspace/didChangeConfiguration".
+	DidChangeConfiguration(ctx context.Context, params *DidChangeConfigurationParams) error
+
+	// DidChangeWatchedFiles handles the notification "workspace/didChangeWatchedFiles".
+	DidChangeWatchedFiles(ctx context.Context, params *DidChangeWatchedFilesParams) error
+
+	// Completion handles the request "textDocument/completion".
+	Completion(ctx context.Context, params *CompletionParams) (*CompletionList, error)
+
+	// ResolveCompletionItem handles the request "completionItem/resolve".
+	ResolveCompletionItem(ctx context.Context, params *CompletionItem) (*CompletionItem, error)
+
+	// Hover handles the request "textDocument/hover".
+	Hover(ctx context.Context, params *TextDocumentPositionParams) (*Hover, error)
+
+	// SignatureHelp handles the request "textDocument/signatureHelp".
+	SignatureHelp(ctx context.Context, params *TextDocumentPositionParams) (*SignatureHelp, error)
+
+	// Definition handles the request "textDocument/definition".
+	Definition(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error)
+
+	// TypeDefinition handles the request "textDocument/typeDefinition".
+	TypeDefinition(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error)
+
+	// Implementation handles the request "textDocument/implementation".
+	Implementation(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error)
+
+	// References handles the request "textDocument/references".
+	References(ctx context.Context, params *ReferenceParams) ([]Location, error)
+
+	// DocumentHighlight handles the request "textDocument/documentHighlight".
+	DocumentHighlight(ctx context.Context, params *TextDocumentPositionParams) ([]DocumentHighlight, error)
+
+	// DocumentSymbol handles the request "textDocument/documentSymbol".
+	DocumentSymbol(ctx context.Context, params *DocumentSymbolParams) ([]SymbolInformation, error)
+
+	// WorkspaceSymbol handles the request "workspace/symbol".
+	WorkspaceSymbol(ctx context.Context, params *WorkspaceSymbolParams) ([]SymbolInformation, error)
+
+	// CodeAction handles the request "textDocument/codeAction".
+	CodeAction(ctx context.Context, params *CodeActionParams) ([]CommandOrCodeAction, error)
+
+	// ResolveCodeAction handles the request "codeAction/resolve".
+	ResolveCodeAction(ctx context.Context, params *CodeAction) (*CodeAction, error)
+
+	// CodeLens handles the request "textDocument/codeLens".
+	CodeLens(ctx context.Context, params *CodeLensParams) ([]CodeLens, error)
+
+	// ResolveCodeLens handles the request "codeLens/resolve".
+	ResolveCodeLens(ctx context.Context, params *CodeLens) (*CodeLens, error)
+
+	// Formatting handles the request "textDocument/formatting".
+	Formatting(ctx context.Context, params *DocumentFormattingParams) ([]TextEdit, error)
+
+	// RangeFormatting handles the request "textDocument/rangeFormatting".
+	RangeFormatting(ctx context.Context, params *DocumentRangeFormattingParams) ([]TextEdit, error)
+
+	// OnTypeFormatting handles the request "textDocument/onTypeFormatting".
+	OnTypeFormatting(ctx context.Context, params *DocumentOnTypeFormattingParams) ([]TextEdit, error)
+
+	// Rename handles the request "textDocument/rename".
+	Rename(ctx context.Context, params *RenameParams) (*WorkspaceEdit, error)
+
+	// SemanticTokensFull handles the request "textDocument/semanticTokens/full".
+	SemanticTokensFull(ctx context.Context, params *SemanticTokensParams) (*SemanticTokens, error)
+
+	// SemanticTokensFullDelta handles the request "textDocument/semanticTokens/full/delta".
+	SemanticTokensFullDelta(ctx context.Context, params *SemanticTokensDeltaParams) (*SemanticTokensOrDelta, error)
+
+	// SemanticTokensRange handles the request "textDocument/semanticTokens/range".
+	SemanticTokensRange(ctx context.Context, params *SemanticTokensRangeParams) (*SemanticTokens, error)
+
+	// PrepareCallHierarchy handles the request "textDocument/prepareCallHierarchy".
+	PrepareCallHierarchy(ctx context.Context, params *CallHierarchyPrepareParams) ([]CallHierarchyItem, error)
+
+	// IncomingCalls handles the request "callHierarchy/incomingCalls".
+	IncomingCalls(ctx context.Context, params *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, error)
+
+	// OutgoingCalls handles the request "callHierarchy/outgoingCalls".
+	OutgoingCalls(ctx context.Context, params *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, error)
+
+	// PrepareTypeHierarchy handles the request "textDocument/prepareTypeHierarchy".
+	PrepareTypeHierarchy(ctx context.Context, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error)
+
+	// Supertypes handles the request "typeHierarchy/supertypes".
+	Supertypes(ctx context.Context, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error)
+
+	// Subtypes handles the request "typeHierarchy/subtypes".
+	Subtypes(ctx context.Context, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error)
+
+	// ExecuteCommand handles the request "workspace/executeCommand".
+	ExecuteCommand(ctx context.Context, params *ExecuteCommandParams) (interface{}, error)
+}
+
+// UnimplementedLanguageServer implements every method of
+// LanguageServer by returning an error with code CodeMethodNotFound.
+type UnimplementedLanguageServer struct{}
+
+func (UnimplementedLanguageServer) Initialize(ctx context.Context, params *InitializeParams) (*InitializeResult, error) {
+	return nil, errMethodNotFound(MethodInitialize)
+}
+
+func (UnimplementedLanguageServer) Initialized(ctx context.Context, params *InitializedParams) error {
+	return errMethodNotFound(MethodInitialized)
+}
+
+func (UnimplementedLanguageServer) Shutdown(ctx context.Context) error {
+	return errMethodNotFound(MethodShutdown)
+}
+
+func (UnimplementedLanguageServer) Exit(ctx context.Context) error {
+	return errMethodNotFound(MethodExit)
+}
+
+func (UnimplementedLanguageServer) DidOpen(ctx context.Context, params *DidOpenTextDocumentParams) error {
+	return errMethodNotFound(MethodDidOpen)
+}
+
+func (UnimplementedLanguageServer) DidChange(ctx context.Context, params *DidChangeTextDocumentParams) error {
+	return errMethodNotFound(MethodDidChange)
+}
+
+func (UnimplementedLanguageServer) DidClose(ctx context.Context, params *DidCloseTextDocumentParams) error {
+	return errMethodNotFound(MethodDidClose)
+}
+
+func (UnimplementedLanguageServer) DidSave(ctx context.Context, params *DidSaveTextDocumentParams) error {
+	return errMethodNotFound(MethodDidSave)
+}
+
+func (UnimplementedLanguageServer) DidChangeConfiguration(ctx context.Context, params *DidChangeConfigurationParams) error {
+	return errMethodNotFound(MethodDidChangeConfiguration)
+}
+
+func (UnimplementedLanguageServer) DidChangeWatchedFiles(ctx context.Context, params *DidChangeWatchedFilesParams) error {
+	return errMethodNotFound(MethodDidChangeWatchedFiles)
+}
+
+func (UnimplementedLanguageServer) Completion(ctx context.Context, params *CompletionParams) (*CompletionList, error) {
+	return nil, errMethodNotFound(MethodCompletion)
+}
+
+func (UnimplementedLanguageServer) ResolveCompletionItem(ctx context.Context, params *CompletionItem) (*CompletionItem, error) {
+	return nil, errMethodNotFound(MethodResolveCompletionItem)
+}
+
+func (UnimplementedLanguageServer) Hover(ctx context.Context, params *TextDocumentPositionParams) (*Hover, error) {
+	return nil, errMethodNotFound(MethodHover)
+}
+
+func (UnimplementedLanguageServer) SignatureHelp(ctx context.Context, params *TextDocumentPositionParams) (*SignatureHelp, error) {
+	return nil, errMethodNotFound(MethodSignatureHelp)
+}
+
+func (UnimplementedLanguageServer) Definition(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error) {
+	return nil, errMethodNotFound(MethodDefinition)
+}
+
+func (UnimplementedLanguageServer) TypeDefinition(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error) {
+	return nil, errMethodNotFound(MethodTypeDefinition)
+}
+
+func (UnimplementedLanguageServer) Implementation(ctx context.Context, params *TextDocumentPositionParams) ([]Location, error) {
+	return nil, errMethodNotFound(MethodImplementation)
+}
+
+func (UnimplementedLanguageServer) References(ctx context.Context, params *ReferenceParams) ([]Location, error) {
+	return nil, errMethodNotFound(MethodReferences)
+}
+
+func (UnimplementedLanguageServer) DocumentHighlight(ctx context.Context, params *TextDocumentPositionParams) ([]DocumentHighlight, error) {
+	return nil, errMethodNotFound(MethodDocumentHighlight)
+}
+
+func (UnimplementedLanguageServer) DocumentSymbol(ctx context.Context, params *DocumentSymbolParams) ([]SymbolInformation, error) {
+	return nil, errMethodNotFound(MethodDocumentSymbol)
+}
+
+func (UnimplementedLanguageServer) WorkspaceSymbol(ctx context.Context, params *WorkspaceSymbolParams) ([]SymbolInformation, error) {
+	return nil, errMethodNotFound(MethodWorkspaceSymbol)
+}
+
+func (UnimplementedLanguageServer) CodeAction(ctx context.Context, params *CodeActionParams) ([]CommandOrCodeAction, error) {
+	return nil, errMethodNotFound(MethodCodeAction)
+}
+
+func (UnimplementedLanguageServer) ResolveCodeAction(ctx context.Context, params *CodeAction) (*CodeAction, error) {
+	return nil, errMethodNotFound(MethodResolveCodeAction)
+}
+
+func (UnimplementedLanguageServer) CodeLens(ctx context.Context, params *CodeLensParams) ([]CodeLens, error) {
+	return nil, errMethodNotFound(MethodCodeLens)
+}
+
+func (UnimplementedLanguageServer) ResolveCodeLens(ctx context.Context, params *CodeLens) (*CodeLens, error) {
+	return nil, errMethodNotFound(MethodResolveCodeLens)
+}
+
+func (UnimplementedLanguageServer) Formatting(ctx context.Context, params *DocumentFormattingParams) ([]TextEdit, error) {
+	return nil, errMethodNotFound(MethodFormatting)
+}
+
+func (UnimplementedLanguageServer) RangeFormatting(ctx context.Context, params *DocumentRangeFormattingParams) ([]TextEdit, error) {
+	return nil, errMethodNotFound(MethodRangeFormatting)
+}
+
+func (UnimplementedLanguageServer) OnTypeFormatting(ctx context.Context, params *DocumentOnTypeFormattingParams) ([]TextEdit, error) {
+	return nil, errMethodNotFound(MethodOnTypeFormatting)
+}
+
+func (UnimplementedLanguageServer) Rename(ctx context.Context, params *RenameParams) (*WorkspaceEdit, error) {
+	return nil, errMethodNotFound(MethodRename)
+}
+
+func (UnimplementedLanguageServer) SemanticTokensFull(ctx context.Context, params *SemanticTokensParams) (*SemanticTokens, error) {
+	return nil, errMethodNotFound(MethodSemanticTokensFull)
+}
+
+func (UnimplementedLanguageServer) SemanticTokensFullDelta(ctx context.Context, params *SemanticTokensDeltaParams) (*SemanticTokensOrDelta, error) {
+	return nil, errMethodNotFound(MethodSemanticTokensFullDelta)
+}
+
+func (UnimplementedLanguageServer) SemanticTokensRange(ctx context.Context, params *SemanticTokensRangeParams) (*SemanticTokens, error) {
+	return nil, errMethodNotFound(MethodSemanticTokensRange)
+}
+
+func (UnimplementedLanguageServer) PrepareCallHierarchy(ctx context.Context, params *CallHierarchyPrepareParams) ([]CallHierarchyItem, error) {
+	return nil, errMethodNotFound(MethodPrepareCallHierarchy)
+}
+
+func (UnimplementedLanguageServer) IncomingCalls(ctx context.Context, params *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, error) {
+	return nil, errMethodNotFound(MethodIncomingCalls)
+}
+
+func (UnimplementedLanguageServer) OutgoingCalls(ctx context.Context, params *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, error) {
+	return nil, errMethodNotFound(MethodOutgoingCalls)
+}
+
+func (UnimplementedLanguageServer) PrepareTypeHierarchy(ctx context.Context, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, error) {
+	return nil, errMethodNotFound(MethodPrepareTypeHierarchy)
+}
+
+func (UnimplementedLanguageServer) Supertypes(ctx context.Context, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, error) {
+	return nil, errMethodNotFound(MethodSupertypes)
+}
+
+func (UnimplementedLanguageServer) Subtypes(ctx context.Context, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, error) {
+	return nil, errMethodNotFound(MethodSubtypes)
+}
+
+func (UnimplementedLanguageServer) ExecuteCommand(ctx context.Context, params *ExecuteCommandParams) (interface{}, error) {
+	return nil, errMethodNotFound(MethodExecuteCommand)
+}
+
+func dispatchServerRequest(ctx context.Context, s LanguageServer, method string, rawParams *json.RawMessage) (interface{}, error) {
+	switch method {
+	case MethodInitialize:
+		var params InitializeParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Initialize(ctx, &params)
+	case MethodShutdown:
+		return nil, s.Shutdown(ctx)
+	case MethodCompletion:
+		var params CompletionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Completion(ctx, &params)
+	case MethodResolveCompletionItem:
+		var params CompletionItem
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.ResolveCompletionItem(ctx, &params)
+	case MethodHover:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Hover(ctx, &params)
+	case MethodSignatureHelp:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.SignatureHelp(ctx, &params)
+	case MethodDefinition:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Definition(ctx, &params)
+	case MethodTypeDefinition:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.TypeDefinition(ctx, &params)
+	case MethodImplementation:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Implementation(ctx, &params)
+	case MethodReferences:
+		var params ReferenceParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.References(ctx, &params)
+	case MethodDocumentHighlight:
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.DocumentHighlight(ctx, &params)
+	case MethodDocumentSymbol:
+		var params DocumentSymbolParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.DocumentSymbol(ctx, &params)
+	case MethodWorkspaceSymbol:
+		var params WorkspaceSymbolParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.WorkspaceSymbol(ctx, &params)
+	case MethodCodeAction:
+		var params CodeActionParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.CodeAction(ctx, &params)
+	case MethodResolveCodeAction:
+		var params CodeAction
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.ResolveCodeAction(ctx, &params)
+	case MethodCodeLens:
+		var params CodeLensParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.CodeLens(ctx, &params)
+	case MethodResolveCodeLens:
+		var params CodeLens
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.ResolveCodeLens(ctx, &params)
+	case MethodFormatting:
+		var params DocumentFormattingParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Formatting(ctx, &params)
+	case MethodRangeFormatting:
+		var params DocumentRangeFormattingParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.RangeFormatting(ctx, &params)
+	case MethodOnTypeFormatting:
+		var params DocumentOnTypeFormattingParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.OnTypeFormatting(ctx, &params)
+	case MethodRename:
+		var params RenameParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Rename(ctx, &params)
+	case MethodSemanticTokensFull:
+		var params SemanticTokensParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.SemanticTokensFull(ctx, &params)
+	case MethodSemanticTokensFullDelta:
+		var params SemanticTokensDeltaParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.SemanticTokensFullDelta(ctx, &params)
+	case MethodSemanticTokensRange:
+		var params SemanticTokensRangeParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.SemanticTokensRange(ctx, &params)
+	case MethodPrepareCallHierarchy:
+		var params CallHierarchyPrepareParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.PrepareCallHierarchy(ctx, &params)
+	case MethodIncomingCalls:
+		var params CallHierarchyIncomingCallsParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.IncomingCalls(ctx, &params)
+	case MethodOutgoingCalls:
+		var params CallHierarchyOutgoingCallsParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.OutgoingCalls(ctx, &params)
+	case MethodPrepareTypeHierarchy:
+		var params TypeHierarchyPrepareParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.PrepareTypeHierarchy(ctx, &params)
+	case MethodSupertypes:
+		var params TypeHierarchySupertypesParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Supertypes(ctx, &params)
+	case MethodSubtypes:
+		var params TypeHierarchySubtypesParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.Subtypes(ctx, &params)
+	case MethodExecuteCommand:
+		var params ExecuteCommandParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return nil, err
+		}
+		return s.ExecuteCommand(ctx, &params)
+	}
+	return nil, errMethodNotFound(method)
+}
+
+func dispatchServerNotification(ctx context.Context, s LanguageServer, method string, rawParams *json.RawMessage) error {
+	switch method {
+	case MethodInitialized:
+		var params InitializedParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.Initialized(ctx, &params)
+	case MethodExit:
+		return s.Exit(ctx)
+	case MethodDidOpen:
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidOpen(ctx, &params)
+	case MethodDidChange:
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidChange(ctx, &params)
+	case MethodDidClose:
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidClose(ctx, &params)
+	case MethodDidSave:
+		var params DidSaveTextDocumentParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidSave(ctx, &params)
+	case MethodDidChangeConfiguration:
+		var params DidChangeConfigurationParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidChangeConfiguration(ctx, &params)
+	case MethodDidChangeWatchedFiles:
+		var params DidChangeWatchedFilesParams
+		if err := unmarshalParams(rawParams, &params); err != nil {
+			return err
+		}
+		return s.DidChangeWatchedFiles(ctx, &params)
+	}
+	return errMethodNotFound(method)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"strings"
 )
 
@@ -25,15 +26,21 @@ type InitializeParams struct {
 	WorkDoneToken string `json:"workDoneToken,omitempty"`
 }
 
-// Root returns the RootURI if set, or otherwise the RootPath with 'file://' prepended.
+// Root returns the RootURI if set, or otherwise the file URI of the
+// RootPath (see URIFromPath). Some clients send a URI in RootPath,
+// which is returned as is, as is a relative RootPath (which has no
+// file URI).
 func (p *InitializeParams) Root() DocumentURI {
 	if p.RootURI != "" {
 		return p.RootURI
 	}
-	if strings.HasPrefix(p.RootPath, "file://") {
+	if p.RootPath == "" {
+		return ""
+	}
+	if strings.HasPrefix(p.RootPath, "file://") || !isAbsPath(p.RootPath) {
 		return DocumentURI(p.RootPath)
 	}
-	return DocumentURI("file://" + p.RootPath)
+	return URIFromPath(p.RootPath)
 }
 
 type DocumentURI string
@@ -49,6 +56,7 @@ type ClientCapabilities struct {
 	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
 	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
 	Window       WindowClientCapabilities       `json:"window,omitempty"`
+	General      GeneralClientCapabilities      `json:"general,omitempty"`
 	Experimental interface{}                    `json:"experimental,omitempty"`
 
 	// Below are Sourcegraph extensions. They do not live in lspext since
@@ -69,8 +77,14 @@ type ClientCapabilities struct {
 
 type WorkspaceClientCapabilities struct {
 	WorkspaceEdit struct {
-		DocumentChanges    bool     `json:"documentChanges,omitempty"`
-		ResourceOperations []string `json:"resourceOperations,omitempty"`
+		DocumentChanges       bool     `json:"documentChanges,omitempty"`
+		ResourceOperations    []string `json:"resourceOperations,omitempty"`
+		FailureHandling       string   `json:"failureHandling,omitempty"`
+		NormalizesLineEndings bool     `json:"normalizesLineEndings,omitempty"`
+
+		ChangeAnnotationSupport *struct {
+			GroupsOnLabel bool `json:"groupsOnLabel,omitempty"`
+		} `json:"changeAnnotationSupport,omitempty"`
 	} `json:"workspaceEdit,omitempty"`
 
 	ApplyEdit bool `json:"applyEdit,omitempty"`
@@ -145,11 +159,40 @@ type TextDocumentClientCapabilities struct {
 		SemanticHighlighting bool `json:"semanticHighlighting,omitempty"`
 	} `json:"semanticHighlightingCapabilities,omitempty"`
 
+	SemanticTokens *struct {
+		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+
+		Requests struct {
+			Range SemanticTokensRangeSupport       `json:"range,omitempty"`
+			Full  *SemanticTokensFullOptionsOrBool `json:"full,omitempty"`
+		} `json:"requests"`
+
+		TokenTypes []SemanticTokenType `json:"tokenTypes"`
+
+		TokenModifiers []SemanticTokenModifier `json:"tokenModifiers"`
+
+		Formats []TokenFormat `json:"formats"`
+
+		OverlappingTokenSupport bool `json:"overlappingTokenSupport,omitempty"`
+
+		MultilineTokenSupport bool `json:"multilineTokenSupport,omitempty"`
+	} `json:"semanticTokens,omitempty"`
+
 	CodeAction struct {
 		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 
 		IsPreferredSupport bool `json:"isPreferredSupport,omitempty"`
 
+		DisabledSupport bool `json:"disabledSupport,omitempty"`
+
+		DataSupport bool `json:"dataSupport,omitempty"`
+
+		HonorsChangeAnnotations bool `json:"honorsChangeAnnotations,omitempty"`
+
+		ResolveSupport *struct {
+			Properties []string `json:"properties"`
+		} `json:"resolveSupport,omitempty"`
+
 		CodeActionLiteralSupport struct {
 			CodeActionKind struct {
 				ValueSet []CodeActionKind `json:"valueSet,omitempty"`
@@ -200,6 +243,10 @@ type TextDocumentClientCapabilities struct {
 		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 	} `json:"callHierarchy,omitempty"`
 
+	TypeHierarchy *struct {
+		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	} `json:"typeHierarchy,omitempty"`
+
 	ColorProvider *struct {
 		DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 	} `json:"colorProvider,omitempty"`
@@ -209,6 +256,13 @@ type WindowClientCapabilities struct {
 	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
 }
 
+type GeneralClientCapabilities struct {
+	// PositionEncodings are the position encodings supported by the
+	// client, in decreasing order of preference. If omitted, only
+	// PEKUTF16 is supported.
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
+}
+
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities,omitempty"`
 }
@@ -217,6 +271,10 @@ type InitializeError struct {
 	Retry bool `json:"retry"`
 }
 
+// InitializedParams is the (empty) params of the "initialized"
+// notification.
+type InitializedParams struct{}
+
 type ResourceOperation string
 
 const (
@@ -307,7 +365,7 @@ type ServerCapabilities struct {
 	DocumentSymbolProvider           bool                             `json:"documentSymbolProvider,omitempty"`
 	WorkspaceSymbolProvider          bool                             `json:"workspaceSymbolProvider,omitempty"`
 	ImplementationProvider           bool                             `json:"implementationProvider,omitempty"`
-	CodeActionProvider               bool                             `json:"codeActionProvider,omitempty"`
+	CodeActionProvider               *CodeActionOptionsOrBool         `json:"codeActionProvider,omitempty"`
 	CodeLensProvider                 *CodeLensOptions                 `json:"codeLensProvider,omitempty"`
 	DocumentFormattingProvider       bool                             `json:"documentFormattingProvider,omitempty"`
 	DocumentRangeFormattingProvider  bool                             `json:"documentRangeFormattingProvider,omitempty"`
@@ -315,6 +373,14 @@ type ServerCapabilities struct {
 	RenameProvider                   bool                             `json:"renameProvider,omitempty"`
 	ExecuteCommandProvider           *ExecuteCommandOptions           `json:"executeCommandProvider,omitempty"`
 	SemanticHighlighting             *SemanticHighlightingOptions     `json:"semanticHighlighting,omitempty"`
+	SemanticTokensProvider           *SemanticTokensOptions           `json:"semanticTokensProvider,omitempty"`
+	CallHierarchyProvider            bool                             `json:"callHierarchyProvider,omitempty"`
+	TypeHierarchyProvider            bool                             `json:"typeHierarchyProvider,omitempty"`
+
+	// PositionEncoding is the position encoding chosen by the server
+	// among those in the client's general.positionEncodings
+	// capability. If omitted, it is PEKUTF16.
+	PositionEncoding PositionEncodingKind `json:"positionEncoding,omitempty"`
 
 	// XWorkspaceReferencesProvider indicates the server provides support for
 	// xworkspace/references. This is a Sourcegraph extension.
@@ -468,6 +534,7 @@ const (
 	CAKRefactorRewrite       CodeActionKind = "refactor.rewrite"
 	CAKSource                CodeActionKind = "source"
 	CAKSourceOrganizeImports CodeActionKind = "source.organizeImports"
+	CAKSourceFixAll          CodeActionKind = "source.fixAll"
 )
 
 type InsertTextFormat int
@@ -678,14 +745,168 @@ type ConfigurationResult []interface{}
 
 type CodeActionContext struct {
 	Diagnostics []Diagnostic `json:"diagnostics"`
+
+	// Only, if set, restricts the code actions to return to those of
+	// these kinds (or of their sub-kinds).
+	Only []CodeActionKind `json:"only,omitempty"`
+
+	// TriggerKind is the reason why code actions were requested.
+	TriggerKind CodeActionTriggerKind `json:"triggerKind,omitempty"`
 }
 
+// CodeActionTriggerKind is the reason why code actions were requested.
+type CodeActionTriggerKind int
+
+const (
+	// CATKInvoked means that code actions were explicitly requested by
+	// the user or by an extension.
+	CATKInvoked CodeActionTriggerKind = 1
+
+	// CATKAutomatic means that code actions were requested
+	// automatically, typically because the selection changed.
+	CATKAutomatic CodeActionTriggerKind = 2
+)
+
 type CodeActionParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 	Range        Range                  `json:"range"`
 	Context      CodeActionContext      `json:"context"`
 }
 
+// CodeAction is a change that can be performed in code, such as a
+// quick fix or a refactoring. It must set Edit, Command, or both (in
+// which case the edit is applied first).
+type CodeAction struct {
+	Title string `json:"title"`
+
+	Kind CodeActionKind `json:"kind,omitempty"`
+
+	// Diagnostics are the diagnostics that this code action resolves.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+
+	// IsPreferred marks this as a preferred action, such as the most
+	// likely fix for a diagnostic.
+	IsPreferred bool `json:"isPreferred,omitempty"`
+
+	// Disabled, if set, says why this code action cannot currently be
+	// applied.
+	Disabled *CodeActionDisabled `json:"disabled,omitempty"`
+
+	Edit *WorkspaceEdit `json:"edit,omitempty"`
+
+	Command *Command `json:"command,omitempty"`
+
+	// Data is preserved between a textDocument/codeAction request and
+	// a codeAction/resolve request.
+	Data interface{} `json:"data,omitempty"`
+}
+
+type CodeActionDisabled struct {
+	// Reason is a human-readable description of why the code action is
+	// disabled, shown in the user interface.
+	Reason string `json:"reason"`
+}
+
+// CommandOrCodeAction holds an element of the result of a
+// textDocument/codeAction request, which is either a Command or a
+// CodeAction. Exactly one field must be set.
+type CommandOrCodeAction struct {
+	Command    *Command
+	CodeAction *CodeAction
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *CommandOrCodeAction) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	switch {
+	case v.Command != nil && v.CodeAction == nil:
+		return json.Marshal(v.Command)
+	case v.CodeAction != nil && v.Command == nil:
+		return json.Marshal(v.CodeAction)
+	}
+	return nil, errors.New("lsp: CommandOrCodeAction must have exactly one field set")
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A value is a Command if
+// its "command" field is a string.
+func (v *CommandOrCodeAction) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = CommandOrCodeAction{}
+		return nil
+	}
+	var peek struct {
+		Command json.RawMessage `json:"command"`
+	}
+	if err := json.Unmarshal(data, &peek); err != nil {
+		return err
+	}
+	if len(peek.Command) > 0 && peek.Command[0] == '"' {
+		var tmp Command
+		if err := json.Unmarshal(data, &tmp); err != nil {
+			return err
+		}
+		*v = CommandOrCodeAction{Command: &tmp}
+		return nil
+	}
+	var tmp CodeAction
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*v = CommandOrCodeAction{CodeAction: &tmp}
+	return nil
+}
+
+type CodeActionOptions struct {
+	// CodeActionKinds are the kinds of code actions that the server may
+	// return.
+	CodeActionKinds []CodeActionKind `json:"codeActionKinds,omitempty"`
+
+	// ResolveProvider reports whether the server supports
+	// codeAction/resolve.
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+// CodeActionOptionsOrBool holds either a bool or CodeActionOptions. The
+// LSP API allows either to be specified in the
+// (ServerCapabilities).CodeActionProvider field.
+type CodeActionOptionsOrBool struct {
+	Bool    bool
+	Options *CodeActionOptions
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *CodeActionOptionsOrBool) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	if v.Options != nil {
+		return json.Marshal(v.Options)
+	}
+	return json.Marshal(v.Bool)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. If CodeActionOptions are
+// given, Bool is set to true.
+func (v *CodeActionOptionsOrBool) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = CodeActionOptionsOrBool{}
+		return nil
+	}
+	var b bool
+	if err := json.Unmarshal(data, &b); err == nil {
+		*v = CodeActionOptionsOrBool{Bool: b}
+		return nil
+	}
+	var tmp CodeActionOptions
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return err
+	}
+	*v = CodeActionOptionsOrBool{Bool: true, Options: &tmp}
+	return nil
+}
+
 type CodeLensParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
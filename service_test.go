@@ -58,6 +58,97 @@ func TestTextDocumentSyncOptionsOrKind_MarshalUnmarshalJSON(t *testing.T) {
 	}
 }
 
+func TestCodeActionOptionsOrBool_MarshalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want *CodeActionOptionsOrBool
+	}{
+		{data: []byte(`true`), want: &CodeActionOptionsOrBool{Bool: true}},
+		{data: []byte(`false`), want: &CodeActionOptionsOrBool{}},
+		{
+			data: []byte(`{"codeActionKinds":["quickfix"],"resolveProvider":true}`),
+			want: &CodeActionOptionsOrBool{
+				Bool:    true,
+				Options: &CodeActionOptions{CodeActionKinds: []CodeActionKind{CAKQuickFix}, ResolveProvider: true},
+			},
+		},
+	}
+	for _, test := range tests {
+		var got CodeActionOptionsOrBool
+		if err := json.Unmarshal(test.data, &got); err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(&got, test.want) {
+			t.Errorf("got %+v, want %+v", got, test.want)
+			continue
+		}
+		data, err := json.Marshal(&got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("got JSON %q, want %q", data, test.data)
+		}
+	}
+}
+
+func TestCommandOrCodeAction_MarshalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want CommandOrCodeAction
+	}{
+		{
+			data: []byte(`{"title":"Run","command":"run","arguments":["x"]}`),
+			want: CommandOrCodeAction{Command: &Command{Title: "Run", Command: "run", Arguments: []interface{}{"x"}}},
+		},
+		{
+			data: []byte(`{"title":"Fix","kind":"quickfix","isPreferred":true,"command":{"title":"Fix","command":"fix","arguments":null}}`),
+			want: CommandOrCodeAction{CodeAction: &CodeAction{
+				Title:       "Fix",
+				Kind:        CAKQuickFix,
+				IsPreferred: true,
+				Command:     &Command{Title: "Fix", Command: "fix"},
+			}},
+		},
+		{
+			data: []byte(`{"title":"Extract","kind":"refactor.extract","disabled":{"reason":"no selection"},"data":{"id":1}}`),
+			want: CommandOrCodeAction{CodeAction: &CodeAction{
+				Title:    "Extract",
+				Kind:     CAKRefactorExtract,
+				Disabled: &CodeActionDisabled{Reason: "no selection"},
+				Data:     map[string]interface{}{"id": float64(1)},
+			}},
+		},
+	}
+	for _, test := range tests {
+		var got CommandOrCodeAction
+		if err := json.Unmarshal(test.data, &got); err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got %+v, want %+v", got, test.want)
+			continue
+		}
+		data, err := json.Marshal(&got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("got JSON %q, want %q", data, test.data)
+		}
+	}
+
+	for _, v := range []*CommandOrCodeAction{{}, {Command: &Command{}, CodeAction: &CodeAction{}}} {
+		if data, err := json.Marshal(v); err == nil {
+			t.Errorf("%+v: got JSON %q, want error", v, data)
+		}
+	}
+}
+
 func TestMarkedString_MarshalUnmarshalJSON(t *testing.T) {
 	tests := []struct {
 		data []byte
@@ -0,0 +1,206 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxContentLength is the maximum message body size accepted by
+// a MessageReader whose MaxContentLength field is zero.
+const DefaultMaxContentLength = 32 << 20
+
+const (
+	// maxHeaderLine is the maximum length of a single header line,
+	// including the line terminator.
+	maxHeaderLine = 4096
+
+	// maxHeaderSize is the maximum total size of a message's header
+	// part, including the empty line that terminates it.
+	maxHeaderSize = 16 << 10
+)
+
+// ErrMissingContentLength is returned by (*MessageReader).ReadMessage
+// when a message's header part does not contain a Content-Length
+// header.
+var ErrMissingContentLength = errors.New("lsp: missing Content-Length header")
+
+// ErrHeaderTooLarge is returned by (*MessageReader).ReadMessage when a
+// header line or a message's header part exceeds the size limits.
+var ErrHeaderTooLarge = errors.New("lsp: message header too large")
+
+// HeaderError describes a malformed header line.
+type HeaderError struct {
+	// Line is the offending header line, without its line terminator.
+	Line string
+
+	// Reason describes what is wrong with the line.
+	Reason string
+}
+
+func (e *HeaderError) Error() string {
+	return fmt.Sprintf("lsp: invalid header %q: %s", e.Line, e.Reason)
+}
+
+// MessageTooLargeError is returned by (*MessageReader).ReadMessage when
+// a message's Content-Length exceeds the reader's limit. The body of
+// the message is not consumed.
+type MessageTooLargeError struct {
+	Length int64 // the Content-Length of the message
+	Max    int64 // the maximum accepted Content-Length
+}
+
+func (e *MessageTooLargeError) Error() string {
+	return fmt.Sprintf("lsp: message body of %d bytes exceeds limit of %d bytes", e.Length, e.Max)
+}
+
+// CharsetError is returned by (*MessageReader).ReadMessage when a
+// message's Content-Type header specifies a charset other than UTF-8.
+type CharsetError struct {
+	Charset string
+}
+
+func (e *CharsetError) Error() string {
+	return fmt.Sprintf("lsp: unsupported charset %q", e.Charset)
+}
+
+// MessageReader reads messages framed using the LSP base protocol: a
+// header part of "Name: value" lines (of which only Content-Length is
+// required), an empty line, and a body of exactly Content-Length bytes.
+//
+// Header lines may be terminated by either "\r\n" (as the
+// specification requires) or "\n".
+type MessageReader struct {
+	// MaxContentLength is the maximum accepted message body size. If
+	// zero, DefaultMaxContentLength is used.
+	MaxContentLength int64
+
+	r *bufio.Reader
+}
+
+// NewMessageReader returns a MessageReader that reads messages from r.
+func NewMessageReader(r io.Reader) *MessageReader {
+	return &MessageReader{r: bufio.NewReaderSize(r, maxHeaderLine)}
+}
+
+// ReadMessage reads the next message and returns its body. It returns
+// io.EOF if the underlying reader is exhausted before any byte of a
+// message has been read, and io.ErrUnexpectedEOF if it is exhausted in
+// the middle of a message.
+func (r *MessageReader) ReadMessage() ([]byte, error) {
+	length, err := r.readHeader()
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return body, nil
+}
+
+// readHeader reads a message's header part and returns the value of
+// its Content-Length header.
+func (r *MessageReader) readHeader() (int64, error) {
+	max := r.MaxContentLength
+	if max == 0 {
+		max = DefaultMaxContentLength
+	}
+
+	length := int64(-1)
+	size := 0
+	for first := true; ; first = false {
+		line, err := r.r.ReadSlice('\n')
+		size += len(line)
+		switch {
+		case err == bufio.ErrBufferFull || size > maxHeaderSize:
+			return 0, ErrHeaderTooLarge
+		case err == io.EOF && first && len(line) == 0:
+			return 0, io.EOF
+		case err == io.EOF:
+			return 0, io.ErrUnexpectedEOF
+		case err != nil:
+			return 0, err
+		}
+
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if len(line) == 0 {
+			break
+		}
+
+		colon := bytes.IndexByte(line, ':')
+		if colon <= 0 {
+			return 0, &HeaderError{Line: string(line), Reason: "missing header name or colon"}
+		}
+		name := strings.TrimSpace(string(line[:colon]))
+		value := strings.TrimSpace(string(line[colon+1:]))
+		switch strings.ToLower(name) {
+		case "content-length":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return 0, &HeaderError{Line: string(line), Reason: "Content-Length is not a non-negative integer"}
+			}
+			if length != -1 && n != length {
+				return 0, &HeaderError{Line: string(line), Reason: "conflicting Content-Length headers"}
+			}
+			length = n
+		case "content-type":
+			_, params, err := mime.ParseMediaType(value)
+			if err != nil {
+				return 0, &HeaderError{Line: string(line), Reason: err.Error()}
+			}
+			// "utf8" is accepted for backward compatibility, as
+			// the specification requires.
+			if charset, ok := params["charset"]; ok {
+				if c := strings.ToLower(charset); c != "utf-8" && c != "utf8" {
+					return 0, &CharsetError{Charset: charset}
+				}
+			}
+		}
+	}
+
+	if length == -1 {
+		return 0, ErrMissingContentLength
+	}
+	if length > max {
+		return 0, &MessageTooLargeError{Length: length, Max: max}
+	}
+	return length, nil
+}
+
+// MessageWriter writes messages framed using the LSP base protocol.
+// It is safe for concurrent use; each message is written with a single
+// call to the underlying writer's Write method.
+type MessageWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewMessageWriter returns a MessageWriter that writes messages to w.
+func NewMessageWriter(w io.Writer) *MessageWriter {
+	return &MessageWriter{w: w}
+}
+
+// WriteMessage writes a message with the given body, preceded by its
+// Content-Length header.
+func (w *MessageWriter) WriteMessage(body []byte) error {
+	buf := make([]byte, 0, len(body)+32)
+	buf = append(buf, "Content-Length: "...)
+	buf = strconv.AppendInt(buf, int64(len(body)), 10)
+	buf = append(buf, "\r\n\r\n"...)
+	buf = append(buf, body...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.w.Write(buf)
+	return err
+}
@@ -0,0 +1,183 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMessageReader(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    []string
+		wantErr error
+	}{
+		"crlf": {
+			input: "Content-Length: 2\r\n\r\n{}",
+			want:  []string{"{}"},
+		},
+		"lf": {
+			input: "Content-Length: 2\n\n{}",
+			want:  []string{"{}"},
+		},
+		"multiple": {
+			input: "Content-Length: 1\r\n\r\n1Content-Length: 2\r\n\r\n22",
+			want:  []string{"1", "22"},
+		},
+		"case-insensitive name and content type": {
+			input: "content-type: application/vscode-jsonrpc; charset=utf8\r\nCONTENT-LENGTH:3\r\n\r\nabc",
+			want:  []string{"abc"},
+		},
+		"unknown header": {
+			input: "X-Foo: bar\r\nContent-Length: 0\r\n\r\n",
+			want:  []string{""},
+		},
+		"repeated identical length": {
+			input: "Content-Length: 1\r\nContent-Length: 1\r\n\r\nx",
+			want:  []string{"x"},
+		},
+		"empty": {
+			input: "",
+		},
+		"missing length": {
+			input:   "Content-Type: application/vscode-jsonrpc\r\n\r\n{}",
+			wantErr: ErrMissingContentLength,
+		},
+		"truncated header": {
+			input:   "Content-Length: 2\r\n",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		"truncated body": {
+			input:   "Content-Length: 3\r\n\r\n{}",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		"header line too long": {
+			input:   "X-Foo: " + strings.Repeat("a", 5000) + "\r\nContent-Length: 0\r\n\r\n",
+			wantErr: ErrHeaderTooLarge,
+		},
+		"too many header lines": {
+			input:   strings.Repeat("X-Foo: bar\r\n", 2000) + "Content-Length: 0\r\n\r\n",
+			wantErr: ErrHeaderTooLarge,
+		},
+	}
+	for label, test := range tests {
+		for _, oneByte := range []bool{false, true} {
+			var r io.Reader = strings.NewReader(test.input)
+			if oneByte {
+				r = iotest.OneByteReader(r)
+			}
+			mr := NewMessageReader(r)
+			var got []string
+			var err error
+			for {
+				var body []byte
+				body, err = mr.ReadMessage()
+				if err != nil {
+					break
+				}
+				got = append(got, string(body))
+			}
+			if test.wantErr == nil && err == io.EOF {
+				err = nil
+			}
+			if err != test.wantErr {
+				t.Errorf("%s (one byte: %v): got error %v, want %v", label, oneByte, err, test.wantErr)
+				continue
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s (one byte: %v): got %q, want %q", label, oneByte, got, test.want)
+			}
+		}
+	}
+}
+
+func TestMessageReader_errors(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		max   int64
+		check func(error) bool
+	}{
+		"no colon": {
+			input: "Content-Length 2\r\n\r\n{}",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"empty name": {
+			input: ": 2\r\n\r\n{}",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"negative length": {
+			input: "Content-Length: -1\r\n\r\n",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"non-numeric length": {
+			input: "Content-Length: 2x\r\n\r\n{}",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"conflicting lengths": {
+			input: "Content-Length: 2\r\nContent-Length: 3\r\n\r\n{}",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"malformed content type": {
+			input: "Content-Type: ;;\r\nContent-Length: 2\r\n\r\n{}",
+			check: func(err error) bool { var e *HeaderError; return errors.As(err, &e) },
+		},
+		"unsupported charset": {
+			input: "Content-Type: application/vscode-jsonrpc; charset=latin1\r\nContent-Length: 2\r\n\r\n{}",
+			check: func(err error) bool {
+				var e *CharsetError
+				return errors.As(err, &e) && e.Charset == "latin1"
+			},
+		},
+		"too large": {
+			input: "Content-Length: 11\r\n\r\n",
+			max:   10,
+			check: func(err error) bool {
+				var e *MessageTooLargeError
+				return errors.As(err, &e) && e.Length == 11 && e.Max == 10
+			},
+		},
+		"too large by default": {
+			input: "Content-Length: 9999999999999\r\n\r\n",
+			check: func(err error) bool {
+				var e *MessageTooLargeError
+				return errors.As(err, &e) && e.Max == DefaultMaxContentLength
+			},
+		},
+	}
+	for label, test := range tests {
+		mr := NewMessageReader(strings.NewReader(test.input))
+		mr.MaxContentLength = test.max
+		_, err := mr.ReadMessage()
+		if err == nil || !test.check(err) {
+			t.Errorf("%s: unexpected error %v", label, err)
+		}
+	}
+}
+
+func TestMessageWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewMessageWriter(&buf)
+	for _, body := range []string{`{"a":1}`, ``, `"é"`} {
+		if err := w.WriteMessage([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "Content-Length: 7\r\n\r\n{\"a\":1}Content-Length: 0\r\n\r\nContent-Length: 4\r\n\r\n\"é\""; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	r := NewMessageReader(&buf)
+	for _, want := range []string{`{"a":1}`, ``, `"é"`} {
+		body, err := r.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != want {
+			t.Errorf("got %q, want %q", body, want)
+		}
+	}
+}
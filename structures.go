@@ -1,6 +1,10 @@
 package lsp
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
 
 type Position struct {
 	/**
@@ -9,7 +13,8 @@ type Position struct {
 	Line int `json:"line"`
 
 	/**
-	 * Character offset on a line in a document (zero-based).
+	 * Character offset on a line in a document (zero-based), in code
+	 * units of the negotiated PositionEncodingKind (UTF-16 by default).
 	 */
 	Character int `json:"character"`
 }
@@ -111,7 +116,304 @@ type WorkspaceEdit struct {
 	/**
 	 * Holds changes to existing resources.
 	 */
-	Changes map[string][]TextEdit `json:"changes"`
+	Changes map[string][]TextEdit `json:"changes,omitempty"`
+
+	/**
+	 * Depending on the client capability
+	 * `workspace.workspaceEdit.resourceOperations` document changes are
+	 * either an array of `TextDocumentEdit`s to express changes to n
+	 * different text documents where each text document edit addresses
+	 * a specific version of a text document. Or it can contain above
+	 * `TextDocumentEdit`s mixed with create, rename and delete file /
+	 * folder operations.
+	 *
+	 * If a client neither supports `documentChanges` nor
+	 * `workspace.workspaceEdit.resourceOperations` then only plain
+	 * `TextEdit`s using the `changes` property are supported.
+	 */
+	DocumentChanges []DocumentChange `json:"documentChanges,omitempty"`
+
+	/**
+	 * A map of change annotations that can be referenced in
+	 * `AnnotatedTextEdit`s or create, rename and delete file / folder
+	 * operations.
+	 */
+	ChangeAnnotations map[ChangeAnnotationIdentifier]ChangeAnnotation `json:"changeAnnotations,omitempty"`
+}
+
+/**
+ * An identifier referring to a change annotation managed by a workspace
+ * edit.
+ */
+type ChangeAnnotationIdentifier string
+
+/**
+ * Additional information that describes document changes.
+ */
+type ChangeAnnotation struct {
+	/**
+	 * A human-readable string describing the actual change. The string
+	 * is rendered prominent in the user interface.
+	 */
+	Label string `json:"label"`
+
+	/**
+	 * A flag which indicates that user confirmation is needed
+	 * before applying the change.
+	 */
+	NeedsConfirmation bool `json:"needsConfirmation,omitempty"`
+
+	/**
+	 * A human-readable string which is rendered less prominent in
+	 * the user interface.
+	 */
+	Description string `json:"description,omitempty"`
+}
+
+/**
+ * A special text edit with an additional change annotation. Without an
+ * annotation, it is a plain TextEdit.
+ */
+type AnnotatedTextEdit struct {
+	TextEdit
+
+	/**
+	 * The actual annotation identifier.
+	 */
+	AnnotationID ChangeAnnotationIdentifier `json:"annotationId,omitempty"`
+}
+
+type OptionalVersionedTextDocumentIdentifier struct {
+	TextDocumentIdentifier
+
+	/**
+	 * The version number of this document. If nil, the known state of
+	 * the document is used (encoded as null).
+	 */
+	Version *int `json:"version"`
+}
+
+/**
+ * Describes textual changes on a single text document. The text
+ * document is referred to as an `OptionalVersionedTextDocumentIdentifier`
+ * to allow clients to check the text document version before an edit is
+ * applied.
+ */
+type TextDocumentEdit struct {
+	/**
+	 * The text document to change.
+	 */
+	TextDocument OptionalVersionedTextDocumentIdentifier `json:"textDocument"`
+
+	/**
+	 * The edits to be applied.
+	 */
+	Edits []AnnotatedTextEdit `json:"edits"`
+}
+
+/**
+ * Options to create a file.
+ */
+type CreateFileOptions struct {
+	/**
+	 * Overwrite existing file. Overwrite wins over `ignoreIfExists`.
+	 */
+	Overwrite bool `json:"overwrite,omitempty"`
+
+	/**
+	 * Ignore if exists.
+	 */
+	IgnoreIfExists bool `json:"ignoreIfExists,omitempty"`
+}
+
+/**
+ * Create file operation. It is encoded with a "kind" of "create".
+ */
+type CreateFile struct {
+	/**
+	 * The resource to create.
+	 */
+	URI DocumentURI `json:"uri"`
+
+	/**
+	 * Additional options.
+	 */
+	Options *CreateFileOptions `json:"options,omitempty"`
+
+	/**
+	 * An optional annotation identifier describing the operation.
+	 */
+	AnnotationID ChangeAnnotationIdentifier `json:"annotationId,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c CreateFile) MarshalJSON() ([]byte, error) {
+	type createFile CreateFile
+	return json.Marshal(struct {
+		Kind ResourceOperation `json:"kind"`
+		createFile
+	}{ROCreate, createFile(c)})
+}
+
+/**
+ * Rename file options.
+ */
+type RenameFileOptions struct {
+	/**
+	 * Overwrite target if existing. Overwrite wins over `ignoreIfExists`.
+	 */
+	Overwrite bool `json:"overwrite,omitempty"`
+
+	/**
+	 * Ignores if target exists.
+	 */
+	IgnoreIfExists bool `json:"ignoreIfExists,omitempty"`
+}
+
+/**
+ * Rename file operation. It is encoded with a "kind" of "rename".
+ */
+type RenameFile struct {
+	/**
+	 * The old (existing) location.
+	 */
+	OldURI DocumentURI `json:"oldUri"`
+
+	/**
+	 * The new location.
+	 */
+	NewURI DocumentURI `json:"newUri"`
+
+	/**
+	 * Rename options.
+	 */
+	Options *RenameFileOptions `json:"options,omitempty"`
+
+	/**
+	 * An optional annotation identifier describing the operation.
+	 */
+	AnnotationID ChangeAnnotationIdentifier `json:"annotationId,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r RenameFile) MarshalJSON() ([]byte, error) {
+	type renameFile RenameFile
+	return json.Marshal(struct {
+		Kind ResourceOperation `json:"kind"`
+		renameFile
+	}{RORename, renameFile(r)})
+}
+
+/**
+ * Delete file options.
+ */
+type DeleteFileOptions struct {
+	/**
+	 * Delete the content recursively if a folder is denoted.
+	 */
+	Recursive bool `json:"recursive,omitempty"`
+
+	/**
+	 * Ignore the operation if the file doesn't exist.
+	 */
+	IgnoreIfNotExists bool `json:"ignoreIfNotExists,omitempty"`
+}
+
+/**
+ * Delete file operation. It is encoded with a "kind" of "delete".
+ */
+type DeleteFile struct {
+	/**
+	 * The file to delete.
+	 */
+	URI DocumentURI `json:"uri"`
+
+	/**
+	 * Delete options.
+	 */
+	Options *DeleteFileOptions `json:"options,omitempty"`
+
+	/**
+	 * An optional annotation identifier describing the operation.
+	 */
+	AnnotationID ChangeAnnotationIdentifier `json:"annotationId,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d DeleteFile) MarshalJSON() ([]byte, error) {
+	type deleteFile DeleteFile
+	return json.Marshal(struct {
+		Kind ResourceOperation `json:"kind"`
+		deleteFile
+	}{RODelete, deleteFile(d)})
+}
+
+// DocumentChange holds one of the entries of
+// (WorkspaceEdit).DocumentChanges: a TextDocumentEdit, or a CreateFile,
+// RenameFile or DeleteFile resource operation, distinguished in JSON by
+// their "kind" field. Exactly one field must be set.
+type DocumentChange struct {
+	TextDocumentEdit *TextDocumentEdit
+	CreateFile       *CreateFile
+	RenameFile       *RenameFile
+	DeleteFile       *DeleteFile
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v *DocumentChange) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	var (
+		n   int
+		val interface{}
+	)
+	if v.TextDocumentEdit != nil {
+		n, val = n+1, v.TextDocumentEdit
+	}
+	if v.CreateFile != nil {
+		n, val = n+1, v.CreateFile
+	}
+	if v.RenameFile != nil {
+		n, val = n+1, v.RenameFile
+	}
+	if v.DeleteFile != nil {
+		n, val = n+1, v.DeleteFile
+	}
+	if n != 1 {
+		return nil, fmt.Errorf("lsp: DocumentChange has %d fields set, want exactly 1", n)
+	}
+	return json.Marshal(val)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *DocumentChange) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*v = DocumentChange{}
+		return nil
+	}
+	var kind struct {
+		Kind ResourceOperation `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return err
+	}
+	*v = DocumentChange{}
+	switch kind.Kind {
+	case "":
+		v.TextDocumentEdit = new(TextDocumentEdit)
+		return json.Unmarshal(data, v.TextDocumentEdit)
+	case ROCreate:
+		v.CreateFile = new(CreateFile)
+		return json.Unmarshal(data, v.CreateFile)
+	case RORename:
+		v.RenameFile = new(RenameFile)
+		return json.Unmarshal(data, v.RenameFile)
+	case RODelete:
+		v.DeleteFile = new(DeleteFile)
+		return json.Unmarshal(data, v.DeleteFile)
+	}
+	return fmt.Errorf("lsp: unknown document change kind %q", kind.Kind)
 }
 
 type TextDocumentIdentifier struct {
@@ -0,0 +1,53 @@
+package lsp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWorkspaceEdit_MarshalUnmarshalJSON(t *testing.T) {
+	version := 3
+	edit := &WorkspaceEdit{
+		DocumentChanges: []DocumentChange{
+			{CreateFile: &CreateFile{URI: "file:///b", Options: &CreateFileOptions{IgnoreIfExists: true}}},
+			{TextDocumentEdit: &TextDocumentEdit{
+				TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///a"}, Version: &version},
+				Edits:        []AnnotatedTextEdit{{TextEdit: edit(0, 0, 0, 1, "x"), AnnotationID: "fix"}},
+			}},
+			{TextDocumentEdit: &TextDocumentEdit{TextDocument: OptionalVersionedTextDocumentIdentifier{TextDocumentIdentifier: TextDocumentIdentifier{URI: "file:///b"}}}},
+			{RenameFile: &RenameFile{OldURI: "file:///a", NewURI: "file:///c"}},
+			{DeleteFile: &DeleteFile{URI: "file:///d", Options: &DeleteFileOptions{Recursive: true}}},
+		},
+		ChangeAnnotations: map[ChangeAnnotationIdentifier]ChangeAnnotation{"fix": {Label: "Fix", NeedsConfirmation: true}},
+	}
+	const want = `{"documentChanges":[` +
+		`{"kind":"create","uri":"file:///b","options":{"ignoreIfExists":true}},` +
+		`{"textDocument":{"uri":"file:///a","version":3},"edits":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":1}},"newText":"x","annotationId":"fix"}]},` +
+		`{"textDocument":{"uri":"file:///b","version":null},"edits":null},` +
+		`{"kind":"rename","oldUri":"file:///a","newUri":"file:///c"},` +
+		`{"kind":"delete","uri":"file:///d","options":{"recursive":true}}],` +
+		`"changeAnnotations":{"fix":{"label":"Fix","needsConfirmation":true}}}`
+
+	data, err := json.Marshal(edit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	var got WorkspaceEdit
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&got, edit) {
+		t.Errorf("got %+v, want %+v", got, edit)
+	}
+
+	if _, err := json.Marshal(&DocumentChange{CreateFile: &CreateFile{}, DeleteFile: &DeleteFile{}}); err == nil {
+		t.Error("got nil error for marshaling a DocumentChange with 2 fields set")
+	}
+	if err := json.Unmarshal([]byte(`{"kind":"move"}`), &DocumentChange{}); err == nil {
+		t.Error("got nil error for an unknown kind")
+	}
+}
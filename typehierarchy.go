@@ -0,0 +1,36 @@
+package lsp
+
+type TypeHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+}
+
+// TypeHierarchyItem is a type (such as a class or an interface) in a
+// type hierarchy.
+type TypeHierarchyItem struct {
+	Name   string      `json:"name"`
+	Kind   SymbolKind  `json:"kind"`
+	Tags   []SymbolTag `json:"tags,omitempty"`
+	Detail string      `json:"detail,omitempty"`
+	URI    DocumentURI `json:"uri"`
+
+	// Range is the range of the whole type, including its body and
+	// comments.
+	Range Range `json:"range"`
+
+	// SelectionRange is the range of the name of the type, within
+	// Range.
+	SelectionRange Range `json:"selectionRange"`
+
+	// Data is preserved between a textDocument/prepareTypeHierarchy
+	// request and the typeHierarchy/supertypes and
+	// typeHierarchy/subtypes requests.
+	Data interface{} `json:"data,omitempty"`
+}
+
+type TypeHierarchySupertypesParams struct {
+	Item TypeHierarchyItem `json:"item"`
+}
+
+type TypeHierarchySubtypesParams struct {
+	Item TypeHierarchyItem `json:"item"`
+}
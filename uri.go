@@ -0,0 +1,178 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// URIFromPath returns the file URI of an absolute path, percent-encoding
+// the characters that need it (such as spaces, '#', '%' and non-ASCII
+// characters).
+//
+// Windows paths are recognized regardless of the current platform:
+// paths starting with a drive letter ("C:\dir" or "C:/dir") become
+// "file:///c:/dir", with the drive letter lowercased as VS Code does,
+// and UNC paths ("\\server\share\dir" or "//server/share/dir") become
+// "file://server/share/dir". Backslashes are treated as separators only
+// in Windows paths.
+//
+// The path is cleaned (see path.Clean), so the URI has no trailing
+// slash, except for the root of a file system or drive.
+//
+// A relative path has no file URI: it is returned as a relative URI
+// reference ("a%20b/c" for "a b/c"), which Path rejects, rather than
+// as a file URI whose first path element would become the host.
+func URIFromPath(p string) DocumentURI {
+	if !isAbsPath(p) {
+		u := url.URL{Path: path.Clean(p)}
+		return DocumentURI(u.String())
+	}
+	var host string
+	switch {
+	case isUNCPath(p):
+		p = strings.Replace(p[2:], `\`, "/", -1)
+		if i := strings.IndexByte(p, '/'); i >= 0 {
+			host, p = p[:i], p[i:]
+		} else {
+			host, p = p, "/"
+		}
+	case isDrivePath(p):
+		p = "/" + strings.ToLower(p[:1]) + ":/" + strings.Replace(p[2:], `\`, "/", -1)
+	}
+	p = path.Clean(p)
+	if isDrivePath(strings.TrimPrefix(p, "/")) && len(p) == 3 {
+		p += "/" // the root of the drive
+	}
+	u := url.URL{Scheme: "file", Host: host, Path: p}
+	return DocumentURI(u.String())
+}
+
+// isAbsPath reports whether p is an absolute path, either a Unix path
+// or a Windows path with a drive letter or UNC prefix.
+func isAbsPath(p string) bool {
+	return strings.HasPrefix(p, "/") || isUNCPath(p) || isDrivePath(p)
+}
+
+// isDrivePath reports whether p starts with a Windows drive letter.
+func isDrivePath(p string) bool {
+	if len(p) < 2 || p[1] != ':' {
+		return false
+	}
+	c := p[0]
+	if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+		return false
+	}
+	return len(p) == 2 || p[2] == '/' || p[2] == '\\'
+}
+
+// isUNCPath reports whether p is a Windows UNC path (\\server\share).
+func isUNCPath(p string) bool {
+	return len(p) > 2 && (strings.HasPrefix(p, `\\`) || strings.HasPrefix(p, "//")) && p[2] != '/' && p[2] != '\\'
+}
+
+// Path returns the file system path of a file URI, decoding its
+// percent-encoded characters. It is an error for u not to be a file
+// URI.
+//
+// Drive letters are returned without a leading slash ("C:/dir"), and
+// URIs with a host other than "localhost" are returned as UNC paths
+// ("//server/share/dir"). The path always uses forward slashes; use
+// filepath.FromSlash to convert it for the current platform.
+//
+// Some clients send malformed URIs with only two slashes before a
+// drive letter ("file://C:/dir"), which are accepted. URIs with a query
+// or fragment (such as those of a file name with an unencoded '#'),
+// with an encoded slash ("%2F"), or with no path are rejected, as they
+// do not name a file unambiguously.
+func (u DocumentURI) Path() (string, error) {
+	pu, err := url.Parse(string(u))
+	if err != nil {
+		return "", fmt.Errorf("lsp: invalid URI %q: %s", u, err)
+	}
+	if !strings.EqualFold(pu.Scheme, "file") {
+		return "", fmt.Errorf("lsp: URI %q is not a file URI", u)
+	}
+	if pu.Opaque != "" {
+		return "", fmt.Errorf("lsp: URI %q has no absolute path", u)
+	}
+	if pu.RawQuery != "" || pu.ForceQuery || pu.Fragment != "" || strings.HasSuffix(string(u), "#") {
+		return "", fmt.Errorf("lsp: file URI %q has a query or fragment", u)
+	}
+	if strings.Contains(strings.ToLower(pu.EscapedPath()), "%2f") {
+		return "", fmt.Errorf("lsp: file URI %q has an encoded slash", u)
+	}
+	if pu.Path == "" && pu.Host == "" {
+		return "", fmt.Errorf("lsp: file URI %q has no path", u)
+	}
+	p := pu.Path
+	switch {
+	case isDrivePath(pu.Host) && len(pu.Host) == 2:
+		p = pu.Host + p
+	case pu.Host != "" && pu.Host != "localhost":
+		p = "//" + pu.Host + p
+	case isDrivePath(strings.TrimPrefix(p, "/")):
+		p = p[1:]
+	}
+	if p == "" {
+		p = "/"
+	}
+	return p, nil
+}
+
+// Normalize returns the canonical form of a file URI: that returned by
+// URIFromPath for its path. This makes equivalent spellings of the same
+// URI equal, such as those differing in the case of the drive letter,
+// in the percent-encoding of characters (for instance "%3A" for ':'),
+// in a trailing slash, or in an explicit "localhost" host. URIs that
+// are not file URIs are returned unchanged.
+func (u DocumentURI) Normalize() DocumentURI {
+	p, err := u.Path()
+	if err != nil {
+		return u
+	}
+	return URIFromPath(p)
+}
+
+// Equal reports whether u and v refer to the same document, after
+// normalization.
+func (u DocumentURI) Equal(v DocumentURI) bool {
+	return u == v || u.Normalize() == v.Normalize()
+}
+
+// Dir returns the URI of the directory containing u, which must be a
+// file URI. The parent of the root of a file system, drive or UNC share
+// is itself.
+func (u DocumentURI) Dir() (DocumentURI, error) {
+	p, err := u.Path()
+	if err != nil {
+		return "", err
+	}
+	pu, err := url.Parse(string(URIFromPath(p)))
+	if err != nil {
+		return "", err
+	}
+	dir := path.Dir(pu.Path)
+	switch {
+	case isDrivePath(strings.TrimPrefix(pu.Path, "/")) && len(dir) <= 3:
+		dir = pu.Path[:3] + "/"
+	case pu.Host != "" && dir == "/":
+		dir = pu.Path
+	}
+	return DocumentURI((&url.URL{Scheme: "file", Host: pu.Host, Path: dir}).String()), nil
+}
+
+// Contains reports whether the document or directory with URI v is u or
+// is inside the directory with URI u, after normalization.
+func (u DocumentURI) Contains(v DocumentURI) bool {
+	u, v = u.Normalize(), v.Normalize()
+	if u == v {
+		return true
+	}
+	prefix := string(u)
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return strings.HasPrefix(string(v), prefix)
+}
@@ -0,0 +1,193 @@
+package lsp
+
+import "testing"
+
+func TestURIFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want DocumentURI
+	}{
+		{path: "/", want: "file:///"},
+		{path: "/home/user/src/main.go", want: "file:///home/user/src/main.go"},
+		{path: "/home/user/src/", want: "file:///home/user/src"},
+		{path: "/a b/c#d/e%f/g?h", want: "file:///a%20b/c%23d/e%25f/g%3Fh"},
+		{path: "/tmp/日本語/ü.go", want: "file:///tmp/%E6%97%A5%E6%9C%AC%E8%AA%9E/%C3%BC.go"},
+		{path: "/a/./b/../c", want: "file:///a/c"},
+		{path: `/a\b`, want: "file:///a%5Cb"}, // not a Windows path
+		{path: `C:\Users\me\main.go`, want: "file:///c:/Users/me/main.go"},
+		{path: "c:/Users/me/", want: "file:///c:/Users/me"},
+		{path: `D:\`, want: "file:///d:/"},
+		{path: "D:", want: "file:///d:/"},
+		{path: `\\server\share\dir\file.go`, want: "file://server/share/dir/file.go"},
+		{path: "//server/share", want: "file://server/share"},
+		{path: "relative/x", want: "relative/x"}, // not a file URI with host "relative"
+		{path: "./a b/c", want: "a%20b/c"},
+	}
+	for _, test := range tests {
+		if got := URIFromPath(test.path); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestDocumentURI_Path(t *testing.T) {
+	tests := []struct {
+		uri  DocumentURI
+		want string
+	}{
+		{uri: "file:///", want: "/"},
+		{uri: "file:///home/user/main.go", want: "/home/user/main.go"},
+		{uri: "file:///a%20b/c%23d/e%25f", want: "/a b/c#d/e%f"},
+		{uri: "file:///tmp/%E6%97%A5%E6%9C%AC%E8%AA%9E", want: "/tmp/日本語"},
+		{uri: "file:///tmp/日本語", want: "/tmp/日本語"},
+		{uri: "file://localhost/etc/hosts", want: "/etc/hosts"},
+		{uri: "FILE:///x", want: "/x"},
+		{uri: "file:/x", want: "/x"},
+		{uri: "file:///c%3A/Users/me/main.go", want: "c:/Users/me/main.go"}, // VS Code
+		{uri: "file:///C:/Users/me/main.go", want: "C:/Users/me/main.go"},
+		{uri: "file://C:/Users/me/main.go", want: "C:/Users/me/main.go"}, // missing slash
+		{uri: "file://server/share/file.go", want: "//server/share/file.go"},
+	}
+	for _, test := range tests {
+		got, err := test.uri.Path()
+		if err != nil {
+			t.Errorf("%q: %s", test.uri, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.uri, got, test.want)
+		}
+	}
+
+	for _, uri := range []DocumentURI{
+		"untitled:Untitled-1",
+		"https://example.com/x",
+		"file:x",
+		"%zz",
+		URIFromPath("relative/x"),
+		"file:",
+		"file://",
+		"file:///x?y=1#z",
+		"file:///x?",
+		"file:///x#",
+		"file:///a#b.go", // unencoded '#' in a file name
+		"file:///a%2Fb",
+		"file:///a%2fb",
+	} {
+		if got, err := uri.Path(); err == nil {
+			t.Errorf("%q: got %q, want error", uri, got)
+		}
+	}
+}
+
+func TestDocumentURI_Normalize(t *testing.T) {
+	tests := []struct {
+		uri  DocumentURI
+		want DocumentURI
+	}{
+		{uri: "file:///c%3A/Users/me/main.go", want: "file:///c:/Users/me/main.go"},
+		{uri: "file:///C:/Users/me/main.go", want: "file:///c:/Users/me/main.go"},
+		{uri: "file://C:/Users/me/", want: "file:///c:/Users/me"},
+		{uri: "file:///home/user/", want: "file:///home/user"},
+		{uri: "file://localhost/home/user", want: "file:///home/user"},
+		{uri: "file:///a%20b/%7Ex", want: "file:///a%20b/~x"},
+		{uri: "file:///a b", want: "file:///a%20b"},
+		{uri: "untitled:Untitled-1", want: "untitled:Untitled-1"},
+	}
+	for _, test := range tests {
+		if got := test.uri.Normalize(); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.uri, got, test.want)
+		}
+		if !test.uri.Equal(test.want) {
+			t.Errorf("%q is not equal to %q", test.uri, test.want)
+		}
+	}
+
+	if DocumentURI("file:///a").Equal("file:///A") {
+		t.Error("paths differing in case are equal")
+	}
+	for _, uri := range []DocumentURI{"file:///a?b=1#c", "file:///a#b", "file:///a%2Fb"} {
+		if base := DocumentURI("file:///a"); uri.Equal(base) || base.Contains(uri) {
+			t.Errorf("%q is equal to or contained in %q", uri, base)
+		}
+	}
+}
+
+func TestDocumentURI_PathRoundTrip(t *testing.T) {
+	for _, p := range []string{"/", "/a b/c#d/e%f/g?h", "/tmp/日本語", "c:/Users/me", "c:/", "//server/share/x"} {
+		got, err := URIFromPath(p).Path()
+		if err != nil {
+			t.Errorf("%q: %s", p, err)
+			continue
+		}
+		if got != p {
+			t.Errorf("%q: got %q after round trip", p, got)
+		}
+	}
+}
+
+func TestDocumentURI_Dir(t *testing.T) {
+	tests := []struct {
+		uri  DocumentURI
+		want DocumentURI
+	}{
+		{uri: "file:///a/b/c.go", want: "file:///a/b"},
+		{uri: "file:///a/b/", want: "file:///a"},
+		{uri: "file:///a", want: "file:///"},
+		{uri: "file:///", want: "file:///"},
+		{uri: "file:///c%3A/Users", want: "file:///c:/"},
+		{uri: "file:///c:/", want: "file:///c:/"},
+		{uri: "file://server/share/x", want: "file://server/share"},
+		{uri: "file://server/share", want: "file://server/share"},
+	}
+	for _, test := range tests {
+		got, err := test.uri.Dir()
+		if err != nil {
+			t.Errorf("%q: %s", test.uri, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.uri, got, test.want)
+		}
+	}
+}
+
+func TestDocumentURI_Contains(t *testing.T) {
+	tests := []struct {
+		dir, uri DocumentURI
+		want     bool
+	}{
+		{dir: "file:///a", uri: "file:///a/b.go", want: true},
+		{dir: "file:///a/", uri: "file:///a/b/c.go", want: true},
+		{dir: "file:///a", uri: "file:///a", want: true},
+		{dir: "file:///a", uri: "file:///ab/c.go", want: false},
+		{dir: "file:///a/b", uri: "file:///a", want: false},
+		{dir: "file:///", uri: "file:///a", want: true},
+		{dir: "file:///C:/Users", uri: "file:///c%3A/Users/me/main.go", want: true},
+		{dir: "file://server/share", uri: "file:///share/x", want: false},
+	}
+	for _, test := range tests {
+		if got := test.dir.Contains(test.uri); got != test.want {
+			t.Errorf("%q contains %q: got %v, want %v", test.dir, test.uri, got, test.want)
+		}
+	}
+}
+
+func TestInitializeParams_Root(t *testing.T) {
+	tests := []struct {
+		params InitializeParams
+		want   DocumentURI
+	}{
+		{params: InitializeParams{RootURI: "file:///a", RootPath: "/b"}, want: "file:///a"},
+		{params: InitializeParams{RootPath: "/my project"}, want: "file:///my%20project"},
+		{params: InitializeParams{RootPath: `C:\src`}, want: "file:///c:/src"},
+		{params: InitializeParams{RootPath: "file:///a"}, want: "file:///a"},
+		{params: InitializeParams{RootPath: "relative/x"}, want: "relative/x"},
+		{params: InitializeParams{}, want: ""},
+	}
+	for _, test := range tests {
+		if got := test.params.Root(); got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.params, got, test.want)
+		}
+	}
+}